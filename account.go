@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/Gobonoid/form/bic"
+	"github.com/Gobonoid/form/modulus"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"time"
 )
 
@@ -21,6 +24,23 @@ type d struct {
 	Data interface{} `json:"data"`
 }
 
+//accountBody is body of responses holding single account
+type accountBody struct {
+	Data *AccountData `json:"data"`
+}
+
+//decodeAccount decodes account from response body, body without data is an error
+func decodeAccount(r io.Reader) (*AccountData, error) {
+	var b accountBody
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, errors.Wrap(err, "failed to decode body")
+	}
+	if b.Data == nil {
+		return nil, errors.New("body has no account data")
+	}
+	return b.Data, nil
+}
+
 //AccountData model as defined by form accounts API
 type AccountData struct {
 	Attributes     *AccountAttributes `json:"attributes,omitempty"`
@@ -44,6 +64,7 @@ type AccountAttributes struct {
 	BaseCurrency            string   `json:"base_currency,omitempty"`
 	Bic                     string   `json:"bic,omitempty"`
 	Country                 *string  `json:"country,omitempty"`
+	CustomerID              string   `json:"customer_id,omitempty"`
 	Iban                    string   `json:"iban,omitempty"`
 	JointAccount            *bool    `json:"joint_account,omitempty"`
 	Name                    []string `json:"name,omitempty"`
@@ -54,14 +75,23 @@ type AccountAttributes struct {
 
 //AccountAPIClient behaves as DI container and provides methods to interact with form accounts API
 type AccountAPIClient struct {
-	c HTTPClient
+	c       HTTPClient
+	rules   *RuleRegistry
+	modulus *modulus.Checker
 }
 
 //NewAccountAPIClient behaves as a construct
-func NewAccountAPIClient(c HTTPClient) *AccountAPIClient {
-	return &AccountAPIClient{
+func NewAccountAPIClient(c HTTPClient, opts ...Option) *AccountAPIClient {
+	a := &AccountAPIClient{
 		c: c,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.rules == nil {
+		a.rules = DefaultRuleRegistry()
+	}
+	return a
 }
 
 //FetchAccountByID using GET request to "/v1/organisation/accounts/{accountID}"
@@ -76,15 +106,11 @@ func (a *AccountAPIClient) FetchAccountByID(ctx context.Context, accountID strin
 	defer resp.Body.Close()
 	switch v := resp.StatusCode; v {
 	case http.StatusOK:
-		b := d{Data: &AccountData{}}
-		if err = json.NewDecoder(resp.Body).Decode(&b); err != nil {
-			return nil, errors.Wrap(err, "failed to decode body")
-		}
-		return b.Data.(*AccountData), nil
+		return decodeAccount(resp.Body)
 	case http.StatusNotFound:
-		return nil, ErrNotFound{}
+		return nil, ErrNotFound{APIError: newAPIError(resp)}
 	default:
-		return nil, ErrUnexpectedStatusCode{StatusCode: v}
+		return nil, ErrUnexpectedStatusCode{StatusCode: v, APIError: newAPIError(resp)}
 	}
 }
 
@@ -96,37 +122,111 @@ type CreateAccountReq struct {
 	Type           string             `json:"type,omitempty"`
 }
 
-//CreateAccount using POST request to const:accountsPath
+//CreateAccount using POST request to const:accountsPath, kept for callers that don't need created account.
+//Any 201 response is success regardless of its body.
 func (a *AccountAPIClient) CreateAccount(ctx context.Context, req CreateAccountReq) error {
-	if err := validateCreateAccountReq(req); err != nil {
+	resp, err := a.postAccount(ctx, req)
+	if err != nil {
 		return err
 	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+//CreateAccountWithResult using POST request to const:accountsPath, returns account as created by API
+//including server assigned Version, CreatedOn and ModifiedOn
+func (a *AccountAPIClient) CreateAccountWithResult(ctx context.Context, req CreateAccountReq) (*AccountData, error) {
+	resp, err := a.postAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decodeAccount(resp.Body)
+}
+
+//postAccount sends creation request and returns 201 response with body to be closed by the caller, other
+//responses are turned into errors
+func (a *AccountAPIClient) postAccount(ctx context.Context, req CreateAccountReq) (*http.Response, error) {
+	req = normaliseCreateAccountReq(req)
+	if err := a.validateCreateAccountReq(req); err != nil {
+		return nil, err
+	}
 	b := new(bytes.Buffer)
 	if err := json.NewEncoder(b).Encode(d{Data: req}); err != nil {
-		return errors.Wrap(err, "failed to marshal payload to json")
+		return nil, errors.Wrap(err, "failed to marshal payload to json")
 	}
 	resp, err := a.c.Post(ctx, accountsPath, b)
 	if err != nil {
-		return errors.Wrap(err, "POST request failed")
+		return nil, errors.Wrap(err, "POST request failed")
+	}
+	if resp.StatusCode == http.StatusCreated {
+		return resp, nil
 	}
 	defer resp.Body.Close()
 
 	switch v := resp.StatusCode; v {
-	case http.StatusCreated:
-		return nil
 	case http.StatusBadRequest:
-		p, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return ErrBadRequest{Reason: "unknown"}
-		}
-		return ErrBadRequest{Reason: string(p)}
+		return nil, newErrBadRequest(resp)
 	case http.StatusConflict:
-		return ErrConflict{Reason: "account already exists"}
+		return nil, ErrConflict{Reason: "account already exists", APIError: newAPIError(resp)}
 	default:
-		return ErrUnexpectedStatusCode{StatusCode: v}
+		return nil, ErrUnexpectedStatusCode{StatusCode: v, APIError: newAPIError(resp)}
 	}
 }
 
+//CreateOrGetAccount creates account or, when account with the same ID already exists and is equivalent to req,
+//returns the existing one, so retried creation is safe. ErrConflictingAccount is returned when existing account differs.
+func (a *AccountAPIClient) CreateOrGetAccount(ctx context.Context, req CreateAccountReq) (*AccountData, error) {
+	req = normaliseCreateAccountReq(req)
+	account, err := a.CreateAccountWithResult(ctx, req)
+	if !errors.Is(err, ErrConflict{}) {
+		return account, err
+	}
+	existing, err := a.FetchAccountByID(ctx, req.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch conflicting account")
+	}
+	if !isEquivalentAccount(req, existing) {
+		return nil, ErrConflictingAccount{Existing: existing, Requested: req}
+	}
+	return existing, nil
+}
+
+//isEquivalentAccount compares only attributes set in req, API populates or defaults the rest e.g. status,
+//generated account number or IBAN and switched or joint account flags
+func isEquivalentAccount(req CreateAccountReq, account *AccountData) bool {
+	if req.ID != account.ID || req.OrganisationID != account.OrganisationID || req.Type != account.Type {
+		return false
+	}
+	requested, err := attributeFields(req.Attributes)
+	if err != nil {
+		return false
+	}
+	existing, err := attributeFields(account.Attributes)
+	if err != nil {
+		return false
+	}
+	for name, value := range requested {
+		if !reflect.DeepEqual(value, existing[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+//attributeFields returns attributes as they're sent to the API, fields which aren't set are left out
+func attributeFields(attrs *AccountAttributes) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if attrs == nil {
+		return fields, nil
+	}
+	b, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	return fields, json.Unmarshal(b, &fields)
+}
+
 //DeleteAccountByID using DELETE request to "/v1/organisation/accounts/{accountID}"
 func (a *AccountAPIClient) DeleteAccountByID(ctx context.Context, accountID string, version int64) error {
 	if err := validateAccountID(accountID); err != nil {
@@ -143,20 +243,89 @@ func (a *AccountAPIClient) DeleteAccountByID(ctx context.Context, accountID stri
 	case http.StatusNoContent:
 		return nil
 	case http.StatusNotFound:
-		return ErrNotFound{}
+		return ErrNotFound{APIError: newAPIError(resp)}
 	case http.StatusConflict:
-		return ErrConflict{Reason: "specified version incorrect"}
+		return ErrConflict{Reason: "specified version incorrect", APIError: newAPIError(resp)}
 	default:
-		return ErrUnexpectedStatusCode{StatusCode: v}
+		return ErrUnexpectedStatusCode{StatusCode: v, APIError: newAPIError(resp)}
 	}
 }
 
-func validateCreateAccountReq(data CreateAccountReq) error {
-	if data.Attributes == nil {
-		return ErrValidationError{Reason: "Attributes property can't be empty"}
+type patchAccountReq struct {
+	Attributes *AccountAttributes `json:"attributes"`
+	ID         string             `json:"id"`
+	Type       string             `json:"type"`
+	Version    int64              `json:"version"`
+}
+
+//PatchAccount using PATCH request to "/v1/organisation/accounts/{accountID}". Only non-empty fields of patch are sent,
+//version has to match the one held by API otherwise ErrConflict is returned.
+func (a *AccountAPIClient) PatchAccount(ctx context.Context, accountID string, version int64, patch AccountAttributes) (*AccountData, error) {
+	if err := validateAccountID(accountID); err != nil {
+		return nil, ErrValidationError{Reason: "accountID isn't uuid"}
+	}
+	if version < 0 {
+		return nil, ErrValidationError{Reason: "version can't be negative"}
+	}
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(d{Data: patchAccountReq{
+		Attributes: &patch,
+		ID:         accountID,
+		Type:       "accounts",
+		Version:    version,
+	}}); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal payload to json")
+	}
+	resp, err := a.c.Patch(ctx, fmt.Sprintf("%s/%s", accountsPath, accountID), b)
+	if err != nil {
+		return nil, errors.Wrap(err, "PATCH request failed")
 	}
+	defer resp.Body.Close()
+	switch v := resp.StatusCode; v {
+	case http.StatusOK:
+		return decodeAccount(resp.Body)
+	case http.StatusBadRequest:
+		return nil, newErrBadRequest(resp)
+	case http.StatusNotFound:
+		return nil, ErrNotFound{APIError: newAPIError(resp)}
+	case http.StatusConflict:
+		conflict := ErrConflict{Reason: "specified version incorrect", APIError: newAPIError(resp)}
+		//API may send the account it holds along with the conflict, it's optional hence decoding errors are ignored
+		var b accountBody
+		if err = json.Unmarshal(conflict.APIError.Body, &b); err == nil && b.Data != nil {
+			conflict.CurrentVersion = b.Data.Version
+		}
+		return nil, conflict
+	default:
+		return nil, ErrUnexpectedStatusCode{StatusCode: v, APIError: newAPIError(resp)}
+	}
+}
+
+//normaliseCreateAccountReq fixes formatting API would reject e.g. lower case or padded BIC, caller's attributes are left untouched
+func normaliseCreateAccountReq(req CreateAccountReq) CreateAccountReq {
+	if req.Attributes == nil || req.Attributes.Bic == "" {
+		return req
+	}
+	attrs := *req.Attributes
+	if b, err := bic.Parse(attrs.Bic); err == nil {
+		attrs.Bic = b.String()
+	} else {
+		attrs.Bic = bic.Normalise(attrs.Bic)
+	}
+	req.Attributes = &attrs
+	return req
+}
 
-	//TODO: extend to do extra validation to avoid BadRequest as much as possible
+//validateCreateAccountReq runs modulus check of the client on top of the registry rules, it's kept out of the registry
+//as the registry may be shared by several clients
+func (a *AccountAPIClient) validateCreateAccountReq(data CreateAccountReq) error {
+	violations := a.rules.validate(data.Attributes)
+	if len(violations) == 0 && a.modulus != nil && *data.Attributes.Country == "GB" {
+		violations = ModulusCheck(a.modulus)(data.Attributes)
+	}
+	if len(violations) > 0 {
+		return violations
+	}
 	return nil
 }
 
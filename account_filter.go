@@ -0,0 +1,49 @@
+package form
+
+import (
+	"net/url"
+	"regexp"
+)
+
+var (
+	countryCodeRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+//AccountFilter narrows down ListAccounts results using form accounts API filter[attribute]=value convention,
+//empty fields are not sent
+type AccountFilter struct {
+	BankID        string
+	BankIDCode    string
+	AccountNumber string
+	Iban          string
+	Country       string
+	CustomerID    string
+	//OrganisationID isn't an attribute but the API filters on it the same way
+	OrganisationID string
+}
+
+//Values encodes filter into query params as expected by form accounts API
+func (f AccountFilter) Values() url.Values {
+	q := url.Values{}
+	for attribute, value := range map[string]string{
+		"bank_id":         f.BankID,
+		"bank_id_code":    f.BankIDCode,
+		"account_number":  f.AccountNumber,
+		"iban":            f.Iban,
+		"country":         f.Country,
+		"customer_id":     f.CustomerID,
+		"organisation_id": f.OrganisationID,
+	} {
+		if value != "" {
+			q.Set("filter["+attribute+"]", value)
+		}
+	}
+	return q
+}
+
+func validateAccountFilter(f AccountFilter) error {
+	if f.Country != "" && !countryCodeRegexp.MatchString(f.Country) {
+		return ErrValidationError{Reason: "filter country has to be ISO 3166-1 alpha-2 code"}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package form
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxPageSize = 100
+)
+
+//Links as defined by JSON:API pagination, each link holds path with query to the given page
+type Links struct {
+	First string `json:"first,omitempty"`
+	Last  string `json:"last,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Self  string `json:"self,omitempty"`
+}
+
+//AccountsPage is a single page of accounts returned by form accounts API
+type AccountsPage struct {
+	Data  []AccountData `json:"data"`
+	Links Links         `json:"links"`
+}
+
+//ListAccountsOpts defines which page of accounts is requested, zero values are left to API defaults
+type ListAccountsOpts struct {
+	PageNumber int
+	PageSize   int
+	Filter     *AccountFilter
+}
+
+func (o ListAccountsOpts) query() url.Values {
+	q := url.Values{}
+	if o.Filter != nil {
+		q = o.Filter.Values()
+	}
+	if o.PageNumber > 0 {
+		q.Set("page[number]", strconv.Itoa(o.PageNumber))
+	}
+	if o.PageSize > 0 {
+		q.Set("page[size]", strconv.Itoa(o.PageSize))
+	}
+	return q
+}
+
+//ListAccounts using GET request to const:accountsPath with page[number] and page[size] query params
+func (a *AccountAPIClient) ListAccounts(ctx context.Context, opts ListAccountsOpts) (*AccountsPage, error) {
+	if err := validateListAccountsOpts(opts); err != nil {
+		return nil, err
+	}
+	return a.listAccounts(ctx, accountsPath, opts.query())
+}
+
+func (a *AccountAPIClient) listAccounts(ctx context.Context, path string, q url.Values) (*AccountsPage, error) {
+	resp, err := a.c.GetWithQueryParams(ctx, path, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "GET request failed")
+	}
+	defer resp.Body.Close()
+	switch v := resp.StatusCode; v {
+	case http.StatusOK:
+		page := &AccountsPage{}
+		if err = json.NewDecoder(resp.Body).Decode(page); err != nil {
+			return nil, errors.Wrap(err, "failed to decode body")
+		}
+		return page, nil
+	case http.StatusBadRequest:
+		return nil, newErrBadRequest(resp)
+	default:
+		return nil, ErrUnexpectedStatusCode{StatusCode: v, APIError: newAPIError(resp)}
+	}
+}
+
+//AccountIterator walks through all accounts page by page following "next" links, pages are fetched lazily
+type AccountIterator struct {
+	ctx  context.Context
+	a    *AccountAPIClient
+	opts ListAccountsOpts
+
+	started bool
+	next    string
+	page    []AccountData
+	current *AccountData
+	err     error
+}
+
+//IterateAccounts returns AccountIterator starting at page defined by opts, no request is made until first Next call
+func (a *AccountAPIClient) IterateAccounts(ctx context.Context, opts ListAccountsOpts) *AccountIterator {
+	return &AccountIterator{
+		ctx:  ctx,
+		a:    a,
+		opts: opts,
+	}
+}
+
+//Next advances iterator to the next account, it returns false when accounts are exhausted,
+//context is cancelled or request failed. Err has to be checked afterwards.
+func (it *AccountIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		if len(it.page) > 0 {
+			it.current = &it.page[0]
+			it.page = it.page[1:]
+			return true
+		}
+		it.current = nil
+		if it.started && it.next == "" {
+			return false
+		}
+		it.fetch()
+	}
+}
+
+func (it *AccountIterator) fetch() {
+	var page *AccountsPage
+	var err error
+	if !it.started {
+		it.started = true
+		page, err = it.a.ListAccounts(it.ctx, it.opts)
+	} else {
+		page, err = it.followNext()
+	}
+	if err != nil {
+		it.err = err
+		return
+	}
+	it.page = page.Data
+	it.next = page.Links.Next
+	if len(page.Data) == 0 || page.Links.Next == page.Links.Self {
+		it.next = ""
+	}
+}
+
+//followNext requests page the next link points at. The link may hold path prefix of the base URL when the API is
+//served under one, HTTPClient adds the prefix itself so the link path is cut to start at accountsPath.
+func (it *AccountIterator) followNext() (*AccountsPage, error) {
+	u, err := url.Parse(it.next)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse next link: %s", it.next)
+	}
+	path := u.Path
+	if i := strings.Index(path, accountsPath); i > 0 {
+		path = path[i:]
+	}
+	return it.a.listAccounts(it.ctx, path, u.Query())
+}
+
+//Account returns account the iterator currently points at
+func (it *AccountIterator) Account() *AccountData {
+	return it.current
+}
+
+//Err returns error which stopped the iteration, nil when accounts were simply exhausted
+func (it *AccountIterator) Err() error {
+	return it.err
+}
+
+func validateListAccountsOpts(opts ListAccountsOpts) error {
+	if opts.PageNumber < 0 {
+		return ErrValidationError{Reason: "page number can't be negative"}
+	}
+	if opts.PageSize < 0 || opts.PageSize > maxPageSize {
+		return ErrValidationError{Reason: "page size has to be between 0 and " + strconv.Itoa(maxPageSize)}
+	}
+	if opts.Filter != nil {
+		return validateAccountFilter(*opts.Filter)
+	}
+	return nil
+}
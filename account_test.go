@@ -2,12 +2,19 @@ package form_test
 
 import (
 	"context"
+	"fmt"
 	"github.com/Gobonoid/form"
 	"github.com/Gobonoid/form/client"
+	"github.com/Gobonoid/form/formtest"
 	"github.com/google/uuid"
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 )
 
@@ -15,218 +22,407 @@ var (
 	baseURL = os.Getenv("ACCOUNT_API_BASE_URL")
 )
 
-func TestAccountAPIClient_FetchAccountByID(t *testing.T) {
+//TestMain runs tests against in-process fake API unless ACCOUNT_API_BASE_URL points to the real one
+func TestMain(m *testing.M) {
+	if baseURL != "" {
+		os.Exit(m.Run())
+	}
+	srv := formtest.NewServer()
+	baseURL = srv.URL
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
 
-	ctx := context.Background()
+//TestAccountContract runs the same conformance suite as the fake API package does, pointing ACCOUNT_API_BASE_URL
+//to the real API detects drift between them
+func TestAccountContract(t *testing.T) {
+	formtest.RunAccountContract(t, func(t *testing.T) form.HTTPClient {
+		c, err := client.NewDefaultClient(baseURL)
+		require.NoError(t, err)
+		return c
+	})
+}
+
+func TestAccountFilter_Values(t *testing.T) {
+	f := form.AccountFilter{
+		BankID:         "400300",
+		BankIDCode:     "GBDSC",
+		AccountNumber:  "41426819",
+		Country:        "GB",
+		OrganisationID: "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+	}
+	assert.Equal(t,
+		"filter%5Baccount_number%5D=41426819&filter%5Bbank_id%5D=400300&filter%5Bbank_id_code%5D=GBDSC&filter%5Bcountry%5D=GB"+
+			"&filter%5Borganisation_id%5D=eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+		f.Values().Encode())
+	assert.Empty(t, form.AccountFilter{}.Values())
+}
 
-	c, err := client.NewDefaultClient(baseURL)
+//TestAccountAPIClient_RejectsBeforeSending covers requests rejected by the client, they're not part of the contract
+//as they never reach the API
+func TestAccountAPIClient_RejectsBeforeSending(t *testing.T) {
+	ctx := context.Background()
+	c, err := client.NewDefaultClient(baseURL, client.WithMiddleware(func(next client.RoundTripFunc) client.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+			return next(req)
+		}
+	}))
 	require.NoError(t, err)
 	accounts := form.NewAccountAPIClient(c)
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
 
 	tests := []struct {
 		name          string
 		expectErrType error
-		accountID     string
-		ctx           context.Context
+		call          func() error
 	}{
 		{
-			name:          "provided accountID isn't UUID",
+			name:          "create invalid account",
+			expectErrType: form.ValidationErrors{},
+			call: func() error {
+				_, err := accounts.CreateAccountWithResult(ctx, form.CreateAccountReq{})
+				return err
+			},
+		},
+		{
+			name:          "create or get invalid account",
+			expectErrType: form.ValidationErrors{},
+			call: func() error {
+				_, err := accounts.CreateOrGetAccount(ctx, form.CreateAccountReq{})
+				return err
+			},
+		},
+		{
+			name:          "fetch accountID isn't UUID",
+			expectErrType: form.ErrValidationError{},
+			call: func() error {
+				_, err := accounts.FetchAccountByID(ctx, "definitely-not-uuid")
+				return err
+			},
+		},
+		{
+			name:          "delete accountID isn't UUID",
+			expectErrType: form.ErrValidationError{},
+			call: func() error {
+				return accounts.DeleteAccountByID(ctx, "definitely-not-uuid", 0)
+			},
+		},
+		{
+			name:          "patch accountID isn't UUID",
+			expectErrType: form.ErrValidationError{},
+			call: func() error {
+				_, err := accounts.PatchAccount(ctx, "definitely-not-uuid", 0, form.AccountAttributes{Name: []string{"renamed account"}})
+				return err
+			},
+		},
+		{
+			name:          "list negative page number",
+			expectErrType: form.ErrValidationError{},
+			call: func() error {
+				_, err := accounts.ListAccounts(ctx, form.ListAccountsOpts{PageNumber: -1})
+				return err
+			},
+		},
+		{
+			name:          "list page size too big",
 			expectErrType: form.ErrValidationError{},
-			accountID:     "definitely-not-uuid",
-			ctx:           ctx,
+			call: func() error {
+				_, err := accounts.ListAccounts(ctx, form.ListAccountsOpts{PageSize: 1000})
+				return err
+			},
 		},
 		{
-			name:          "no account exists",
-			expectErrType: form.ErrNotFound{},
-			accountID:     uuid.New().String(),
-			ctx:           ctx,
+			name:          "list filter country isn't ISO code",
+			expectErrType: form.ErrValidationError{},
+			call: func() error {
+				_, err := accounts.ListAccounts(ctx, form.ListAccountsOpts{Filter: &form.AccountFilter{Country: "gbr"}})
+				return err
+			},
+		},
+		{
+			name: "iterate with cancelled context",
+			call: func() error {
+				it := accounts.IterateAccounts(cancelledCtx, form.ListAccountsOpts{PageSize: 2})
+				assert.False(t, it.Next())
+				assert.ErrorIs(t, it.Err(), context.Canceled)
+				return it.Err()
+			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := accounts.FetchAccountByID(ctx, tt.accountID)
+			err := tt.call()
+			require.Error(t, err)
 			if tt.expectErrType != nil {
-				assert.IsType(t, err, tt.expectErrType)
-				assert.Nil(t, resp)
+				assert.IsType(t, tt.expectErrType, err)
 			}
 		})
 	}
 }
 
-func TestAccountAPIClient(t *testing.T) {
+//TestAccountIterator_PrefixedBaseURL follows next links of API served under path prefix, the links may or may not
+//include the prefix depending on whether proxy in front of the API rewrites them
+func TestAccountIterator_PrefixedBaseURL(t *testing.T) {
+	for _, linkPrefix := range []string{"/sandbox", ""} {
+		t.Run("link prefix "+linkPrefix, func(t *testing.T) {
+			var requested []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = append(requested, r.URL.Path)
+				if r.URL.Path != "/sandbox/v1/organisation/accounts" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				page, _ := strconv.Atoi(r.URL.Query().Get("page[number]"))
+				next := ""
+				if page < 2 {
+					next = linkPrefix + "/v1/organisation/accounts?page%5Bnumber%5D=" + strconv.Itoa(page+1) + "&page%5Bsize%5D=1"
+				}
+				_, _ = fmt.Fprintf(w, `{"data": [{"id": "account-%d"}], "links": {"next": %q}}`, page, next)
+			}))
+			defer srv.Close()
+
+			c, err := client.NewDefaultClient(srv.URL + "/sandbox")
+			require.NoError(t, err)
+			it := form.NewAccountAPIClient(c).IterateAccounts(context.Background(), form.ListAccountsOpts{PageSize: 1})
+			var iterated []string
+			for it.Next() {
+				iterated = append(iterated, it.Account().ID)
+			}
+			require.NoError(t, it.Err())
+			assert.Equal(t, []string{"account-0", "account-1", "account-2"}, iterated)
+			assert.Equal(t, []string{
+				"/sandbox/v1/organisation/accounts",
+				"/sandbox/v1/organisation/accounts",
+				"/sandbox/v1/organisation/accounts",
+			}, requested)
+		})
+	}
+}
+
+func TestAccountAPIClient_PatchAccount(t *testing.T) {
 	ctx := context.Background()
 
-	c, err := client.NewDefaultClient(baseURL)
+	c, err := client.NewDefaultClient(mockBaseURL)
 	require.NoError(t, err)
 	accounts := form.NewAccountAPIClient(c)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
 
-	gbCountryCode := "GB"
+	accountID := uuid.New().String()
+	accountURL := "http://" + mockBaseURL + "/v1/organisation/accounts/" + accountID
 
 	tests := []struct {
 		name          string
+		status        int
+		body          string
 		expectErrType error
-		account       form.CreateAccountReq
-		ctx           context.Context
-		setup         func(accountID string)
+		check         func(t *testing.T, patched *form.AccountData, err error)
 	}{
 		{
-			name:          "invalid account",
-			expectErrType: form.ErrValidationError{},
-			account:       form.CreateAccountReq{},
-			ctx:           ctx,
-		},
-		{
-			name: "duplicate transaction",
-			account: form.CreateAccountReq{
-				Attributes: &form.AccountAttributes{
-					Country: &gbCountryCode,
-					Name:    []string{"fake account"},
-				},
-				ID:             uuid.New().String(),
-				OrganisationID: uuid.New().String(),
-				Type:           "accounts",
-			},
-			ctx: ctx,
-			setup: func(accountID string) {
-				setupErr := accounts.CreateAccount(ctx, form.CreateAccountReq{
-					Attributes: &form.AccountAttributes{
-						Country: &gbCountryCode,
-						Name:    []string{"fake account"},
-					},
-					ID:             accountID,
-					OrganisationID: uuid.New().String(),
-					Type:           "accounts",
-				})
-				require.NoError(t, setupErr)
+			name:          "conflict with current version",
+			status:        http.StatusConflict,
+			body:          `{"data": {"id": "` + accountID + `", "version": 3}, "error_message": "invalid version"}`,
+			expectErrType: form.ErrConflict{},
+			check: func(t *testing.T, _ *form.AccountData, err error) {
+				version := int64(3)
+				assert.Equal(t, &version, err.(form.ErrConflict).CurrentVersion)
+				var apiErr *form.APIError
+				require.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, "invalid version", apiErr.ErrorMessage)
 			},
+		},
+		{
+			name:          "conflict with null data",
+			status:        http.StatusConflict,
+			body:          `{"data": null, "error_message": "invalid version"}`,
 			expectErrType: form.ErrConflict{},
+			check: func(t *testing.T, _ *form.AccountData, err error) {
+				assert.Nil(t, err.(form.ErrConflict).CurrentVersion)
+			},
 		},
 		{
-			name: "bad request",
-			account: form.CreateAccountReq{
-				Attributes: &form.AccountAttributes{
-					Country: &gbCountryCode,
-				},
-				ID:             uuid.New().String(),
-				OrganisationID: uuid.New().String(),
-				Type:           "accounts",
+			name:   "patched without data",
+			status: http.StatusOK,
+			body:   `{"data": null}`,
+			check: func(t *testing.T, _ *form.AccountData, err error) {
+				assert.EqualError(t, err, "body has no account data")
 			},
-			expectErrType: form.ErrBadRequest{},
-			ctx:           ctx,
 		},
 		{
-			name: "success",
-			account: form.CreateAccountReq{
-				Attributes: &form.AccountAttributes{
-					Country: &gbCountryCode,
-					Name:    []string{"fake account"},
-				},
-				ID:             uuid.New().String(),
-				OrganisationID: uuid.New().String(),
-				Type:           "accounts",
+			name:   "patched",
+			status: http.StatusOK,
+			body:   `{"data": {"id": "` + accountID + `", "version": 2, "attributes": {"name": ["renamed"]}}}`,
+			check: func(t *testing.T, patched *form.AccountData, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"renamed"}, patched.Attributes.Name)
+				assert.EqualValues(t, 2, *patched.Version)
 			},
-			ctx: ctx,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.setup != nil {
-				tt.setup(tt.account.ID)
-			}
-			err := accounts.CreateAccount(ctx, tt.account)
+			httpmock.Reset()
+			httpmock.RegisterResponder(http.MethodPatch, accountURL, httpmock.NewStringResponder(tt.status, tt.body))
+			patched, err := accounts.PatchAccount(ctx, accountID, 1, form.AccountAttributes{Name: []string{"renamed"}})
 			if tt.expectErrType != nil {
 				assert.IsType(t, tt.expectErrType, err)
-			} else {
-				assert.NoError(t, err)
-				fetchedAccount, err := accounts.FetchAccountByID(ctx, tt.account.ID)
-				assert.NoError(t, err)
-				assert.Equal(t, tt.account.ID, fetchedAccount.ID)
-				assert.Equal(t, tt.account.OrganisationID, fetchedAccount.OrganisationID)
-				assert.Equal(t, tt.account.Type, fetchedAccount.Type)
-				assert.EqualValues(t, tt.account.Attributes, fetchedAccount.Attributes)
-				assert.False(t, fetchedAccount.CreatedOn.IsZero())
-				assert.False(t, fetchedAccount.ModifiedOn.IsZero())
+				assert.Nil(t, patched)
 			}
+			tt.check(t, patched, err)
 		})
 	}
 }
 
-func TestAccountAPIClient_DeleteAccountByID(t *testing.T) {
+func TestAccountAPIClient_CreatedAndFetchedBody(t *testing.T) {
 	ctx := context.Background()
 
-	c, err := client.NewDefaultClient(baseURL)
+	c, err := client.NewDefaultClient(mockBaseURL)
 	require.NoError(t, err)
 	accounts := form.NewAccountAPIClient(c)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	accountID := uuid.New().String()
+	accountsURL := "http://" + mockBaseURL + "/v1/organisation/accounts"
+	req := form.CreateAccountReq{
+		Attributes:     formtest.ValidGBAttributes("fake account"),
+		ID:             accountID,
+		OrganisationID: uuid.New().String(),
+		Type:           "accounts",
+	}
 
 	tests := []struct {
-		name          string
-		expectErrType error
-		accountID     string
-		ctx           context.Context
-		setup         func(accountID string)
-		version       int64
+		name        string
+		method      string
+		url         string
+		status      int
+		body        string
+		call        func() error
+		expectError string
 	}{
 		{
-			name:          "provided accountID isn't UUID",
-			expectErrType: form.ErrValidationError{},
-			accountID:     "definitely-not-uuid",
-			ctx:           ctx,
-		},
-		{
-			name:          "no account exists",
-			expectErrType: form.ErrNotFound{},
-			accountID:     uuid.New().String(),
-			version:       0,
-			ctx:           ctx,
-		},
-		{
-			name:      "success",
-			accountID: uuid.New().String(),
-			version:   0,
-			ctx:       ctx,
-			setup: func(accountID string) {
-				gbCountryCode := "GB"
-
-				createErr := accounts.CreateAccount(ctx, form.CreateAccountReq{
-					Attributes: &form.AccountAttributes{
-						AccountClassification:   nil,
-						AccountMatchingOptOut:   nil,
-						AccountNumber:           "",
-						AlternativeNames:        nil,
-						BankID:                  "",
-						BankIDCode:              "",
-						BaseCurrency:            "",
-						Bic:                     "",
-						Country:                 &gbCountryCode,
-						Iban:                    "",
-						JointAccount:            nil,
-						Name:                    []string{"fake account"},
-						SecondaryIdentification: "",
-						Status:                  nil,
-						Switched:                nil,
-					},
-					ID:             accountID,
-					OrganisationID: uuid.New().String(),
-					Type:           "accounts",
-				})
-				require.NoError(t, createErr)
+			name:   "created without data",
+			method: http.MethodPost,
+			url:    accountsURL,
+			status: http.StatusCreated,
+			body:   `{"data": null}`,
+			call: func() error {
+				return accounts.CreateAccount(ctx, req)
 			},
 		},
+		{
+			name:   "created without body",
+			method: http.MethodPost,
+			url:    accountsURL,
+			status: http.StatusCreated,
+			call: func() error {
+				return accounts.CreateAccount(ctx, req)
+			},
+		},
+		{
+			name:   "created with result without data",
+			method: http.MethodPost,
+			url:    accountsURL,
+			status: http.StatusCreated,
+			body:   `{"data": null}`,
+			call: func() error {
+				_, err := accounts.CreateAccountWithResult(ctx, req)
+				return err
+			},
+			expectError: "body has no account data",
+		},
+		{
+			name:   "created with result without body",
+			method: http.MethodPost,
+			url:    accountsURL,
+			status: http.StatusCreated,
+			call: func() error {
+				_, err := accounts.CreateAccountWithResult(ctx, req)
+				return err
+			},
+			expectError: "failed to decode body: EOF",
+		},
+		{
+			name:   "fetched without data",
+			method: http.MethodGet,
+			url:    accountsURL + "/" + accountID,
+			status: http.StatusOK,
+			body:   `{"data": null}`,
+			call: func() error {
+				_, err := accounts.FetchAccountByID(ctx, accountID)
+				return err
+			},
+			expectError: "body has no account data",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.setup != nil {
-				tt.setup(tt.accountID)
+			httpmock.Reset()
+			httpmock.RegisterResponder(tt.method, tt.url, httpmock.NewStringResponder(tt.status, tt.body))
+			err := tt.call()
+			if tt.expectError == "" {
+				assert.NoError(t, err)
+				return
 			}
-			err = accounts.DeleteAccountByID(ctx, tt.accountID, tt.version)
+			assert.EqualError(t, err, tt.expectError)
+		})
+	}
+}
+
+func TestAccountAPIClient_CreateOrGetAccount_ServerPopulatedFields(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := client.NewDefaultClient(mockBaseURL)
+	require.NoError(t, err)
+	accounts := form.NewAccountAPIClient(c)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	req := form.CreateAccountReq{
+		Attributes:     formtest.ValidGBAttributes("fake account"),
+		ID:             uuid.New().String(),
+		OrganisationID: uuid.New().String(),
+		Type:           "accounts",
+	}
+	//existing account echoes requested attributes together with ones populated or defaulted by the API
+	existing := func(name string) string {
+		return `{"data": {"id": "` + req.ID + `", "organisation_id": "` + req.OrganisationID + `", "type": "accounts",
+			"version": 0, "attributes": {"account_number": "41426819", "bank_id": "400300", "bank_id_code": "GBDSC",
+			"bic": "NWBKGB22", "country": "GB", "name": ["` + name + `"], "iban": "GB11NWBK40030041426819",
+			"status": "confirmed", "switched": false, "joint_account": false, "account_matching_opt_out": false}}}`
+	}
+	accountsURL := "http://" + mockBaseURL + "/v1/organisation/accounts"
+
+	tests := []struct {
+		name          string
+		existingName  string
+		expectErrType error
+	}{
+		{name: "populated fields are ignored", existingName: "fake account"},
+		{name: "requested fields differ", existingName: "another fake account", expectErrType: form.ErrConflictingAccount{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Reset()
+			httpmock.RegisterResponder(http.MethodPost, accountsURL,
+				httpmock.NewStringResponder(http.StatusConflict, `{"error_message": "account already exists"}`))
+			httpmock.RegisterResponder(http.MethodGet, accountsURL+"/"+req.ID,
+				httpmock.NewStringResponder(http.StatusOK, existing(tt.existingName)))
+
+			account, err := accounts.CreateOrGetAccount(ctx, req)
 			if tt.expectErrType != nil {
-				assert.IsType(t, err, tt.expectErrType)
-			} else {
-				assert.Nil(t, err)
+				assert.IsType(t, tt.expectErrType, err)
+				return
 			}
+			require.NoError(t, err)
+			assert.Equal(t, "confirmed", *account.Attributes.Status)
 		})
 	}
 }
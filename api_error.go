@@ -0,0 +1,81 @@
+package form
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+	//maxErrorBodySize protects from reading huge bodies returned by misbehaving proxies in front of API
+	maxErrorBodySize = 1 << 20
+)
+
+//APIError holds details of non 2xx response returned by form API, typed errors like ErrNotFound wrap it
+type APIError struct {
+	StatusCode   int
+	ErrorCode    string
+	ErrorMessage string
+	RequestID    string
+	Body         []byte
+}
+
+//Error as in error interface implementation
+func (err *APIError) Error() string {
+	msg := fmt.Sprintf("form API responded with status code %d", err.StatusCode)
+	if err.ErrorCode != "" {
+		msg += fmt.Sprintf(" error code %s", err.ErrorCode)
+	}
+	if err.ErrorMessage != "" {
+		msg += ": " + err.ErrorMessage
+	}
+	if err.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %s)", err.RequestID)
+	}
+	return msg
+}
+
+//Temporary reports whether status code signals condition API is expected to recover from i.e. 5xx or 429
+func (err *APIError) Temporary() bool {
+	return isTemporaryStatusCode(err.StatusCode)
+}
+
+//newAPIError reads response body and decodes error details from it, body which isn't JSON is only kept as raw Body
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(requestIDHeader),
+	}
+	p, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return apiErr
+	}
+	apiErr.Body = p
+
+	var b struct {
+		ErrorMessage string      `json:"error_message"`
+		ErrorCode    interface{} `json:"error_code"`
+	}
+	if err = json.Unmarshal(p, &b); err != nil {
+		return apiErr
+	}
+	apiErr.ErrorMessage = b.ErrorMessage
+	if b.ErrorCode != nil {
+		apiErr.ErrorCode = fmt.Sprint(b.ErrorCode)
+	}
+	return apiErr
+}
+
+func newErrBadRequest(resp *http.Response) ErrBadRequest {
+	apiErr := newAPIError(resp)
+	reason := apiErr.ErrorMessage
+	if reason == "" {
+		reason = string(apiErr.Body)
+	}
+	if reason == "" {
+		reason = "unknown"
+	}
+	return ErrBadRequest{Reason: reason, APIError: apiErr}
+}
@@ -0,0 +1,126 @@
+package form_test
+
+import (
+	"context"
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/client"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/google/uuid"
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+const (
+	mockBaseURL = "mock.form.test"
+)
+
+func TestAPIError(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := client.NewDefaultClient(mockBaseURL)
+	require.NoError(t, err)
+	accounts := form.NewAccountAPIClient(c)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	accountID := uuid.New().String()
+	accountURL := "http://" + mockBaseURL + "/v1/organisation/accounts/" + accountID
+	respond := func(status int, body string) httpmock.Responder {
+		resp := httpmock.NewStringResponse(status, body)
+		resp.Header.Set("X-Request-Id", "fake-request-id")
+		return httpmock.ResponderFromResponse(resp)
+	}
+
+	tests := []struct {
+		name           string
+		setup          func()
+		call           func() error
+		expectErrType  error
+		expectAPIError *form.APIError
+		check          func(t *testing.T, err error)
+	}{
+		{
+			name: "not found with error body",
+			setup: func() {
+				httpmock.RegisterResponder(http.MethodGet, accountURL,
+					respond(http.StatusNotFound, `{"error_message": "record does not exist", "error_code": "not_found"}`))
+			},
+			call: func() error {
+				_, err := accounts.FetchAccountByID(ctx, accountID)
+				return err
+			},
+			expectErrType: form.ErrNotFound{},
+			expectAPIError: &form.APIError{
+				StatusCode:   http.StatusNotFound,
+				ErrorCode:    "not_found",
+				ErrorMessage: "record does not exist",
+				RequestID:    "fake-request-id",
+				Body:         []byte(`{"error_message": "record does not exist", "error_code": "not_found"}`),
+			},
+		},
+		{
+			name: "bad request uses error message as reason",
+			setup: func() {
+				httpmock.RegisterResponder(http.MethodPost, "http://"+mockBaseURL+"/v1/organisation/accounts",
+					respond(http.StatusBadRequest, `{"error_message": "validation failure list:\nname in body is required"}`))
+			},
+			call: func() error {
+				return accounts.CreateAccount(ctx, form.CreateAccountReq{
+					Attributes:     formtest.ValidGBAttributes(),
+					ID:             accountID,
+					OrganisationID: uuid.New().String(),
+					Type:           "accounts",
+				})
+			},
+			expectErrType: form.ErrBadRequest{},
+			expectAPIError: &form.APIError{
+				StatusCode:   http.StatusBadRequest,
+				ErrorMessage: "validation failure list:\nname in body is required",
+				RequestID:    "fake-request-id",
+				Body:         []byte(`{"error_message": "validation failure list:\nname in body is required"}`),
+			},
+			check: func(t *testing.T, err error) {
+				assert.Equal(t, "validation failure list:\nname in body is required", err.(form.ErrBadRequest).Reason)
+			},
+		},
+		{
+			name: "unexpected status code with body which isn't JSON",
+			setup: func() {
+				httpmock.RegisterResponder(http.MethodDelete, accountURL, respond(http.StatusBadGateway, "upstream unavailable"))
+			},
+			call: func() error {
+				return accounts.DeleteAccountByID(ctx, accountID, 0)
+			},
+			expectErrType: form.ErrUnexpectedStatusCode{},
+			expectAPIError: &form.APIError{
+				StatusCode: http.StatusBadGateway,
+				RequestID:  "fake-request-id",
+				Body:       []byte("upstream unavailable"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Reset()
+			tt.setup()
+			err := tt.call()
+			require.Error(t, err)
+			if tt.expectErrType != nil {
+				assert.IsType(t, tt.expectErrType, err)
+			}
+			if tt.expectAPIError != nil {
+				var apiErr *form.APIError
+				require.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, tt.expectAPIError, apiErr)
+			}
+			if tt.check != nil {
+				tt.check(t, err)
+			}
+		})
+	}
+}
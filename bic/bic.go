@@ -0,0 +1,81 @@
+package bic
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	primaryOfficeBranch = "XXX"
+)
+
+var (
+	//ErrInvalidLength is returned when BIC isn't 8 or 11 characters long
+	ErrInvalidLength = errors.New("BIC has to be 8 or 11 characters long")
+	//ErrInvalidFormat is returned when BIC parts contain characters they aren't allowed to
+	ErrInvalidFormat = errors.New("invalid format")
+
+	//Pattern matches normalised BIC, Parse relies on it so that callers checking BIC with it agree with Parse
+	Pattern = regexp.MustCompile(`^([A-Z0-9]{4})([A-Z]{2})([A-Z0-9]{2})([A-Z0-9]{3})?$`)
+)
+
+//BIC holds parsed Business Identifier Code (SWIFT code) as defined by ISO 9362
+type BIC struct {
+	Institution string
+	Country     string
+	Location    string
+	//Branch is always set, primary office is identified by XXX
+	Branch string
+}
+
+//Parse accepts BIC regardless of letter case and surrounding or separating whitespace
+func Parse(s string) (*BIC, error) {
+	s = Normalise(s)
+	if len(s) != 8 && len(s) != 11 {
+		return nil, errors.Wrapf(ErrInvalidLength, "%q", s)
+	}
+	m := Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, errors.Wrapf(ErrInvalidFormat, "%q isn't 4 character institution, 2 letter country, 2 character location "+
+			"and optional 3 character branch", s)
+	}
+	b := &BIC{
+		Institution: m[1],
+		Country:     m[2],
+		Location:    m[3],
+		Branch:      m[4],
+	}
+	if b.Branch == "" {
+		b.Branch = primaryOfficeBranch
+	}
+	return b, nil
+}
+
+//Normalise removes whitespace and upper cases BIC without validating it
+func Normalise(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+//IsPrimaryOffice reports whether BIC identifies institution primary office rather than a branch
+func (b *BIC) IsPrimaryOffice() bool {
+	return b.Branch == primaryOfficeBranch
+}
+
+//BIC8 returns 8 character form identifying institution's location without branch
+func (b *BIC) BIC8() string {
+	return b.Institution + b.Country + b.Location
+}
+
+//BIC11 returns 11 character form, primary office has XXX branch
+func (b *BIC) BIC11() string {
+	return b.BIC8() + b.Branch
+}
+
+//String as in fmt.Stringer implementation, returns canonical form which is BIC8 for primary office and BIC11 otherwise
+func (b *BIC) String() string {
+	if b.IsPrimaryOffice() {
+		return b.BIC8()
+	}
+	return b.BIC11()
+}
@@ -0,0 +1,78 @@
+package bic
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expectErr       error
+		expect          *BIC
+		expectCanonical string
+	}{
+		{
+			name:            "BIC8",
+			input:           "NWBKGB22",
+			expect:          &BIC{Institution: "NWBK", Country: "GB", Location: "22", Branch: "XXX"},
+			expectCanonical: "NWBKGB22",
+		},
+		{
+			name:            "BIC11 with branch",
+			input:           "DEUTDEFF500",
+			expect:          &BIC{Institution: "DEUT", Country: "DE", Location: "FF", Branch: "500"},
+			expectCanonical: "DEUTDEFF500",
+		},
+		{
+			name:            "BIC11 of primary office",
+			input:           "NWBKGB22XXX",
+			expect:          &BIC{Institution: "NWBK", Country: "GB", Location: "22", Branch: "XXX"},
+			expectCanonical: "NWBKGB22",
+		},
+		{
+			name:            "lower case and padded",
+			input:           "  nwbk gb 22\t",
+			expect:          &BIC{Institution: "NWBK", Country: "GB", Location: "22", Branch: "XXX"},
+			expectCanonical: "NWBKGB22",
+		},
+		{
+			name:      "too short",
+			input:     "NWBKGB2",
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "9 characters",
+			input:     "NWBKGB22X",
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "country isn't alphabetic",
+			input:     "NWBK1222",
+			expectErr: ErrInvalidFormat,
+		},
+		{
+			name:      "punctuation",
+			input:     "NWBK-GB2",
+			expectErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Parse(tt.input)
+			if tt.expectErr != nil {
+				assert.True(t, errors.Is(err, tt.expectErr), "expected %v got %v", tt.expectErr, err)
+				assert.Nil(t, b)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, b)
+				assert.Equal(t, tt.expectCanonical, b.String())
+				assert.Equal(t, tt.expect.Institution+tt.expect.Country+tt.expect.Location, b.BIC8())
+				assert.Len(t, b.BIC11(), 11)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package client
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//ErrCircuitOpen is returned without sending the request when circuit breaker of the host is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+//windowBuckets is the number of buckets rolling window is split into
+const windowBuckets = 10
+
+//BreakerState is a state of the circuit breaker
+type BreakerState int
+
+//States of the circuit breaker
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+//String as in fmt.Stringer implementation
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+//CircuitBreaker describes when requests to a host start failing fast and how they're let through again
+type CircuitBreaker struct {
+	//FailureRatio of requests within Window that opens the circuit
+	FailureRatio float64
+	//MinRequests that have to be made within Window before FailureRatio is taken into account
+	MinRequests int
+	//Window is the rolling period failures are counted in, it's at least 10ns
+	Window time.Duration
+	//Cooldown is how long circuit stays open before probe requests are let through
+	Cooldown time.Duration
+	//HalfOpenProbes is the number of probe requests which all have to succeed to close the circuit
+	HalfOpenProbes int
+	//IsFailure decides whether request counts as failed, by default transport errors and 5xx responses do
+	IsFailure func(resp *http.Response, err error) bool
+	//OnStateChange is called each time circuit of the host changes its state
+	OnStateChange func(host string, from, to BreakerState)
+}
+
+//DefaultCircuitBreaker opens circuit when half of at least 10 requests within 10 seconds fail and probes the host after 5 seconds
+func DefaultCircuitBreaker() CircuitBreaker {
+	return CircuitBreaker{
+		FailureRatio:   0.5,
+		MinRequests:    10,
+		Window:         10 * time.Second,
+		Cooldown:       5 * time.Second,
+		HalfOpenProbes: 1,
+		IsFailure:      isFailure,
+	}
+}
+
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		//caller giving up doesn't say anything about the host
+		return !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+//breakerGroup keeps separate breaker for every host requests are sent to
+type breakerGroup struct {
+	conf     CircuitBreaker
+	now      func() time.Time
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func newBreakerGroup(cb CircuitBreaker) *breakerGroup {
+	d := DefaultCircuitBreaker()
+	if cb.FailureRatio <= 0 {
+		cb.FailureRatio = d.FailureRatio
+	}
+	if cb.MinRequests <= 0 {
+		cb.MinRequests = d.MinRequests
+	}
+	if cb.Window <= 0 {
+		cb.Window = d.Window
+	}
+	//each bucket has to be at least 1ns wide
+	if cb.Window < windowBuckets {
+		cb.Window = windowBuckets
+	}
+	if cb.Cooldown <= 0 {
+		cb.Cooldown = d.Cooldown
+	}
+	if cb.HalfOpenProbes <= 0 {
+		cb.HalfOpenProbes = d.HalfOpenProbes
+	}
+	if cb.IsFailure == nil {
+		cb.IsFailure = d.IsFailure
+	}
+	return &breakerGroup{
+		conf:     cb,
+		now:      time.Now,
+		breakers: map[string]*breaker{},
+	}
+}
+
+//protect fails fast with ErrCircuitOpen instead of calling next when circuit of the request host is open
+func (g *breakerGroup) protect(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		b := g.breaker(req.URL.Host)
+		probe, err := b.allow()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next(req)
+		b.done(probe, g.conf.IsFailure(resp, err))
+		return resp, err
+	}
+}
+
+func (g *breakerGroup) breaker(host string) *breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, ok := g.breakers[host]
+	if !ok {
+		b = &breaker{host: host, group: g}
+		g.breakers[host] = b
+	}
+	return b
+}
+
+//state returns current state of the host circuit, hosts that weren't requested yet are closed
+func (g *breakerGroup) state(host string) BreakerState {
+	b := g.breaker(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+type bucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+type breaker struct {
+	host  string
+	group *breakerGroup
+
+	mu             sync.Mutex
+	state          BreakerState
+	openedAt       time.Time
+	buckets        [windowBuckets]bucket
+	probesInFlight int
+	probeSuccesses int
+}
+
+//allow reports whether request can be sent and whether it's a probe of half-open circuit
+func (b *breaker) allow() (bool, error) {
+	b.mu.Lock()
+	now := b.group.now()
+	from := b.state
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.group.conf.Cooldown {
+		b.setState(BreakerHalfOpen, now)
+	}
+	to := b.state
+
+	var probe bool
+	var err error
+	switch b.state {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probesInFlight < b.group.conf.HalfOpenProbes-b.probeSuccesses {
+			b.probesInFlight++
+			probe = true
+		} else {
+			err = ErrCircuitOpen
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return probe, err
+}
+
+//done records result of the request
+func (b *breaker) done(probe bool, failed bool) {
+	b.mu.Lock()
+	now := b.group.now()
+	from := b.state
+	switch {
+	case b.state == BreakerHalfOpen && probe:
+		b.probesInFlight--
+		if failed {
+			b.setState(BreakerOpen, now)
+			break
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.group.conf.HalfOpenProbes {
+			b.setState(BreakerClosed, now)
+		}
+	case b.state == BreakerClosed:
+		b.record(now, failed)
+		if successes, failures := b.counts(now); successes+failures >= b.group.conf.MinRequests &&
+			float64(failures)/float64(successes+failures) >= b.group.conf.FailureRatio {
+			b.setState(BreakerOpen, now)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+func (b *breaker) setState(state BreakerState, now time.Time) {
+	b.state = state
+	b.probesInFlight = 0
+	b.probeSuccesses = 0
+	switch state {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerClosed:
+		b.buckets = [windowBuckets]bucket{}
+	}
+}
+
+func (b *breaker) notify(from, to BreakerState) {
+	if from != to && b.group.conf.OnStateChange != nil {
+		b.group.conf.OnStateChange(b.host, from, to)
+	}
+}
+
+func (b *breaker) record(now time.Time, failed bool) {
+	width := b.group.conf.Window / windowBuckets
+	start := now.Truncate(width)
+	bk := &b.buckets[(start.UnixNano()/int64(width))%windowBuckets]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+	if failed {
+		bk.failures++
+	} else {
+		bk.successes++
+	}
+}
+
+func (b *breaker) counts(now time.Time) (successes int, failures int) {
+	for _, bk := range b.buckets {
+		if now.Sub(bk.start) < b.group.conf.Window {
+			successes += bk.successes
+			failures += bk.failures
+		}
+	}
+	return successes, failures
+}
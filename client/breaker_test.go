@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeHost struct {
+	calls  int
+	status int
+	err    error
+}
+
+func (h *fakeHost) roundTrip(req *http.Request) (*http.Response, error) {
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &http.Response{StatusCode: h.status, Body: http.NoBody}, nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var transitions []string
+	clock := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := newBreakerGroup(CircuitBreaker{
+		FailureRatio: 0.5,
+		MinRequests:  4,
+		Window:       time.Second,
+		Cooldown:     5 * time.Second,
+		OnStateChange: func(host string, from, to BreakerState) {
+			transitions = append(transitions, fmt.Sprintf("%s:%s->%s", host, from, to))
+		},
+	})
+	g.now = func() time.Time { return clock }
+
+	host := &fakeHost{status: http.StatusOK}
+	send := g.protect(host.roundTrip)
+	request := func(h string) error {
+		req, err := http.NewRequest(http.MethodGet, "http://"+h+"/v1/organisation/accounts", nil)
+		require.NoError(t, err)
+		_, err = send(req)
+		return err
+	}
+
+	//old failures fall out of the window
+	host.status = http.StatusServiceUnavailable
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, request("a.test"))
+	}
+	clock = clock.Add(2 * time.Second)
+	host.status = http.StatusOK
+	assert.NoError(t, request("a.test"))
+	assert.Equal(t, BreakerClosed, g.state("a.test"))
+
+	//ratio reached once MinRequests were made
+	host.status = http.StatusServiceUnavailable
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, request("a.test"))
+	}
+	assert.Equal(t, BreakerOpen, g.state("a.test"))
+
+	//open circuit fails fast without calling the host
+	calls := host.calls
+	assert.True(t, errors.Is(request("a.test"), ErrCircuitOpen))
+	assert.Equal(t, calls, host.calls)
+
+	//other hosts aren't affected
+	host.status = http.StatusOK
+	assert.NoError(t, request("b.test"))
+	assert.Equal(t, BreakerClosed, g.state("b.test"))
+
+	//failed probe opens the circuit again
+	clock = clock.Add(5 * time.Second)
+	host.err = errors.New("connection refused")
+	assert.Error(t, request("a.test"))
+	assert.Equal(t, BreakerOpen, g.state("a.test"))
+	assert.True(t, errors.Is(request("a.test"), ErrCircuitOpen))
+
+	//successful probe closes it
+	clock = clock.Add(5 * time.Second)
+	host.err = nil
+	assert.NoError(t, request("a.test"))
+	assert.Equal(t, BreakerClosed, g.state("a.test"))
+
+	assert.Equal(t, []string{
+		"a.test:closed->open",
+		"a.test:open->half-open",
+		"a.test:half-open->open",
+		"a.test:open->half-open",
+		"a.test:half-open->closed",
+	}, transitions)
+}
+
+func TestCircuitBreaker_HalfOpenProbes(t *testing.T) {
+	clock := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := newBreakerGroup(CircuitBreaker{MinRequests: 1, Cooldown: time.Second, HalfOpenProbes: 2})
+	g.now = func() time.Time { return clock }
+	b := g.breaker("a.test")
+
+	_, err := b.allow()
+	require.NoError(t, err)
+	b.done(false, true)
+	require.Equal(t, BreakerOpen, g.state("a.test"))
+
+	clock = clock.Add(time.Second)
+	first, err := b.allow()
+	require.NoError(t, err)
+	second, err := b.allow()
+	require.NoError(t, err)
+	assert.True(t, first && second)
+	_, err = b.allow()
+	assert.Equal(t, ErrCircuitOpen, err)
+
+	b.done(first, false)
+	assert.Equal(t, BreakerHalfOpen, g.state("a.test"))
+	b.done(second, false)
+	assert.Equal(t, BreakerClosed, g.state("a.test"))
+}
+
+func TestCircuitBreaker_TinyWindow(t *testing.T) {
+	g := newBreakerGroup(CircuitBreaker{Window: 5 * time.Nanosecond})
+	assert.Equal(t, windowBuckets*time.Nanosecond, g.conf.Window)
+	host := &fakeHost{status: http.StatusOK}
+	req, err := http.NewRequest(http.MethodGet, "http://a.test/v1/organisation/accounts", nil)
+	require.NoError(t, err)
+	_, err = g.protect(host.roundTrip)(req)
+	assert.NoError(t, err)
+}
+
+func TestCircuitBreaker_IgnoresCancelledRequests(t *testing.T) {
+	assert.False(t, isFailure(nil, errors.Wrap(context.Canceled, "request failed")))
+	assert.True(t, isFailure(nil, context.DeadlineExceeded))
+	assert.True(t, isFailure(&http.Response{StatusCode: http.StatusInternalServerError}, nil))
+	assert.False(t, isFailure(&http.Response{StatusCode: http.StatusNotFound}, nil))
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusServiceUnavailable, ""))
+
+	cb := DefaultCircuitBreaker()
+	cb.MinRequests = 2
+	c, err := NewDefaultClient(validTestBaseURL, WithCircuitBreaker(cb), WithRetryPolicy(testRetryPolicy()))
+	require.NoError(t, err)
+
+	//retries stop as soon as circuit opens
+	_, err = c.Get(context.Background(), "/v1/organisation/accounts")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Regexp(t, "request failed.*", err.Error())
+	assert.Equal(t, BreakerOpen, c.CircuitState())
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	_, err = c.Get(context.Background(), "/v1/organisation/accounts")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
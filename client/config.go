@@ -4,6 +4,15 @@ import "net/http"
 
 //Config holds clients configuration values
 type Config struct {
-	c      *http.Client
-	scheme string
+	c           *http.Client
+	scheme      string
+	retry       *RetryPolicy
+	limiter     *rateLimiter
+	breakers    *breakerGroup
+	middlewares []RoundTripMiddleware
+	signer      *Signer
+	tokens      TokenSource
+	tls         *tlsConfig
+	//err is the first error returned by an option, NewDefaultClient fails with it
+	err error
 }
@@ -6,12 +6,15 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 )
 
 //DefaultClient that implements form HttpClient interface and behaves as DI container
 type DefaultClient struct {
-	baseURL string
+	baseURL *url.URL
 	conf    *Config
+	send    RoundTripFunc
 }
 
 //NewDefaultClient behaves as a constructor
@@ -19,7 +22,7 @@ func NewDefaultClient(baseURL string, opts ...Option) (*DefaultClient, error) {
 	if baseURL == "" {
 		return nil, errors.New("empty baseURL")
 	}
-	_, err := url.Parse(baseURL)
+	u, err := parseBaseURL(baseURL)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse baseURL: %s", baseURL)
 	}
@@ -27,31 +30,74 @@ func NewDefaultClient(baseURL string, opts ...Option) (*DefaultClient, error) {
 	for _, opt := range opts {
 		opt(conf)
 	}
+	if conf.err != nil {
+		return nil, conf.err
+	}
+	if u.Scheme != "" && conf.scheme != "" && u.Scheme != conf.scheme {
+		return nil, errors.Errorf("baseURL scheme %s conflicts with %s required by options", u.Scheme, conf.scheme)
+	}
+	if u.Scheme != "" {
+		conf.scheme = u.Scheme
+	}
 	if conf.c == nil {
 		conf.c = http.DefaultClient
 	}
+	if conf.tls != nil {
+		if conf.c, err = conf.tls.build(conf.c); err != nil {
+			return nil, err
+		}
+	}
 	if conf.scheme == "" {
 		conf.scheme = "http"
 	}
 
+	send := RoundTripFunc(conf.c.Do)
+	if conf.signer != nil {
+		//signing goes last so that nothing changes the request once it's signed
+		send = conf.signer.Middleware()(send)
+	}
+	if conf.tokens != nil {
+		send = authorize(conf.tokens)(send)
+	}
+	send = chain(send, conf.middlewares...)
+	if conf.limiter != nil {
+		send = conf.limiter.limit(send)
+	}
+	if conf.breakers != nil {
+		send = conf.breakers.protect(send)
+	}
+	if conf.retry != nil {
+		send = conf.retry.retry(send)
+	}
+
 	return &DefaultClient{
 		conf:    conf,
-		baseURL: baseURL,
+		baseURL: u,
+		send:    send,
 	}, nil
 }
 
 //Get request method implementation
 func (client *DefaultClient) Get(ctx context.Context, path string) (*http.Response, error) {
-	u := &url.URL{
-		Scheme: client.conf.scheme,
-		Host:   client.baseURL,
-		Path:   path,
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.requestURL(path, nil), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create new GET requestWithContext")
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	resp, err := client.send(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+
+	return resp, nil
+}
+
+//GetWithQueryParams is a GET request method implementation with extra query params
+func (client *DefaultClient) GetWithQueryParams(ctx context.Context, path string, q url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.requestURL(path, q), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new GET requestWithContext")
 	}
-	resp, err := client.conf.c.Do(req)
+	resp, err := client.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "request failed")
 	}
@@ -61,16 +107,11 @@ func (client *DefaultClient) Get(ctx context.Context, path string) (*http.Respon
 
 //Post request method implementation
 func (client *DefaultClient) Post(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
-	u := &url.URL{
-		Scheme: client.conf.scheme,
-		Host:   client.baseURL,
-		Path:   path,
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.requestURL(path, nil), body)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new POST RequestWithContext")
 	}
-	resp, err := client.conf.c.Do(req)
+	resp, err := client.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "request failed")
 	}
@@ -78,23 +119,88 @@ func (client *DefaultClient) Post(ctx context.Context, path string, body io.Read
 	return resp, nil
 }
 
-//DeleteWithQueryParams is a DELETE request method implementation with extra query params
-func (client *DefaultClient) DeleteWithQueryParams(ctx context.Context, path string, q url.Values) (*http.Response, error) {
-	u := &url.URL{
-		Scheme:   client.conf.scheme,
-		Host:     client.baseURL,
-		Path:     path,
-		RawQuery: q.Encode(),
+//Patch request method implementation
+func (client *DefaultClient) Patch(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, client.requestURL(path, nil), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create new PATCH RequestWithContext")
+	}
+	resp, err := client.send(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	return resp, nil
+}
+
+//DeleteWithQueryParams is a DELETE request method implementation with extra query params
+func (client *DefaultClient) DeleteWithQueryParams(ctx context.Context, path string, q url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, client.requestURL(path, q), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new DELETE RequestWithContext")
 	}
-	resp, err := client.conf.c.Do(req)
+	resp, err := client.send(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "request failed")
 	}
 
 	return resp, nil
 }
+
+//RateLimitStats returns counters of the rate limiter, all of them are zero when WithRateLimit wasn't used
+func (client *DefaultClient) RateLimitStats() RateLimitStats {
+	if client.conf.limiter == nil {
+		return RateLimitStats{}
+	}
+	return client.conf.limiter.stats()
+}
+
+//CircuitState returns state of the circuit breaker of the client host, it's always closed when WithCircuitBreaker wasn't used
+func (client *DefaultClient) CircuitState() BreakerState {
+	if client.conf.breakers == nil {
+		return BreakerClosed
+	}
+	return client.conf.breakers.state(client.baseURL.Host)
+}
+
+func (client *DefaultClient) requestURL(path string, q url.Values) string {
+	u := &url.URL{
+		Scheme:   client.conf.scheme,
+		Host:     client.baseURL.Host,
+		Path:     client.baseURL.Path + path,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+//parseBaseURL accepts full URL e.g. https://api.example.com:8443/sandbox as well as host with optional port and path
+//e.g. api.example.com or [::1]:8080/sandbox, Scheme of returned URL is empty when baseURL didn't specify it
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	raw := baseURL
+	if !strings.Contains(baseURL, "://") {
+		raw = "//" + baseURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "", "http", "https":
+	default:
+		return nil, errors.Errorf("unsupported scheme %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("missing host")
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("only scheme, host, port and path are allowed")
+	}
+	if port := u.Port(); port != "" {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return nil, errors.Errorf("invalid port %s", port)
+		}
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
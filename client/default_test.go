@@ -7,9 +7,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -68,6 +71,94 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestNewClient_BaseURL(t *testing.T) {
+	tests := []struct {
+		name             string
+		baseURL          string
+		options          []Option
+		expectURL        string
+		expectErrMessage string
+	}{
+		{
+			name:      "host only",
+			baseURL:   "test.com",
+			expectURL: "http://test.com/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:      "host only with HTTPS",
+			baseURL:   "test.com",
+			options:   []Option{WithHTTPS()},
+			expectURL: "https://test.com/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:      "host with port and path prefix",
+			baseURL:   "localhost:8080/sandbox/",
+			expectURL: "http://localhost:8080/sandbox/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:      "full URL",
+			baseURL:   "https://api.example.com/sandbox",
+			expectURL: "https://api.example.com/sandbox/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:      "full URL with matching option",
+			baseURL:   "https://api.example.com:8443",
+			options:   []Option{WithHTTPS()},
+			expectURL: "https://api.example.com:8443/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:      "IPv4 with port",
+			baseURL:   "127.0.0.1:8080",
+			expectURL: "http://127.0.0.1:8080/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:      "IPv6 with port",
+			baseURL:   "http://[::1]:8080/api",
+			expectURL: "http://[::1]:8080/api/v1/organisation/accounts?page%5Bsize%5D=10",
+		},
+		{
+			name:             "unsupported scheme",
+			baseURL:          "ftp://test.com",
+			expectErrMessage: "failed to parse baseURL: ftp://test.com: unsupported scheme ftp",
+		},
+		{
+			name:             "missing host",
+			baseURL:          "https:///sandbox",
+			expectErrMessage: "failed to parse baseURL: https:///sandbox: missing host",
+		},
+		{
+			name:             "query",
+			baseURL:          "https://test.com?debug=true",
+			expectErrMessage: "failed to parse baseURL: https://test.com\\?debug=true: only scheme, host, port and path are allowed",
+		},
+		{
+			name:             "invalid port",
+			baseURL:          "test.com:99999",
+			expectErrMessage: "failed to parse baseURL: test.com:99999: invalid port 99999",
+		},
+		{
+			name:             "scheme conflicting with options",
+			baseURL:          "http://test.com",
+			options:          []Option{WithHTTPS()},
+			expectErrMessage: "baseURL scheme http conflicts with https required by options",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewDefaultClient(tt.baseURL, tt.options...)
+			if tt.expectErrMessage != "" {
+				assert.Error(t, err)
+				assert.Nil(t, c)
+				assert.Regexp(t, "^"+tt.expectErrMessage+"$", err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectURL, c.requestURL("/v1/organisation/accounts", url.Values{"page[size]": []string{"10"}}))
+		})
+	}
+}
+
 func TestGet(t *testing.T) {
 	ctx := context.Background()
 	fakeErr := errors.New("fake error")
@@ -198,3 +289,163 @@ func TestPost(t *testing.T) {
 		})
 	}
 }
+
+func TestPatch(t *testing.T) {
+	ctx := context.Background()
+	fakeErr := errors.New("fake error")
+
+	c, err := NewDefaultClient(validTestBaseURL)
+	require.NoError(t, err)
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	tests := []struct {
+		name             string
+		expectErrMessage string
+		ctx              context.Context
+		path             string
+		setup            func(path, respBody string)
+		reqBody          string
+	}{
+		{
+			name:             "failed to create request",
+			expectErrMessage: "failed to create new PATCH RequestWithContext",
+			ctx:              nil,
+		},
+		{
+			name:             "no response",
+			ctx:              ctx,
+			path:             "/IWontRespondHere",
+			expectErrMessage: "request failed.*",
+			setup: func(path, respBody string) {
+				httpmock.RegisterNoResponder(httpmock.NewErrorResponder(fakeErr))
+			},
+		},
+		{
+			name: "success",
+			ctx:  ctx,
+			path: "/something",
+			setup: func(path string, reqBody string) {
+				httpmock.RegisterResponder(http.MethodPatch, c.conf.scheme+"://"+validTestBaseURL+path,
+					func(req *http.Request) (*http.Response, error) {
+						p, err := io.ReadAll(req.Body)
+						req.Body.Close()
+						assert.NoError(t, err)
+						assert.Equal(t, string(p), reqBody)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+						}, nil
+					})
+			},
+			reqBody: "fakeString",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.path, tt.reqBody)
+			}
+			resp, err := c.Patch(tt.ctx, tt.path, strings.NewReader(tt.reqBody))
+			if tt.expectErrMessage != "" {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Regexp(t, tt.expectErrMessage, err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			}
+		})
+	}
+}
+
+func TestGetWithQueryParams(t *testing.T) {
+	ctx := context.Background()
+	fakeErr := errors.New("fake error")
+
+	c, err := NewDefaultClient(validTestBaseURL)
+	require.NoError(t, err)
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	tests := []struct {
+		name             string
+		expectErrMessage string
+		ctx              context.Context
+		path             string
+		query            url.Values
+		setup            func(path string, q url.Values)
+	}{
+		{
+			name:             "failed to create request",
+			expectErrMessage: "failed to create new GET requestWithContext",
+			ctx:              nil,
+		},
+		{
+			name:             "no response",
+			ctx:              ctx,
+			path:             "/IWontRespondHere",
+			expectErrMessage: "request failed.*",
+			setup: func(path string, q url.Values) {
+				httpmock.RegisterNoResponder(httpmock.NewErrorResponder(fakeErr))
+			},
+		},
+		{
+			name:  "success",
+			ctx:   ctx,
+			path:  "/something",
+			query: url.Values{"page[number]": []string{"1"}, "page[size]": []string{"10"}},
+			setup: func(path string, q url.Values) {
+				httpmock.RegisterResponderWithQuery(http.MethodGet, c.conf.scheme+"://"+validTestBaseURL+path, q,
+					httpmock.NewStringResponder(http.StatusOK, `{"data": []}`))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.path, tt.query)
+			}
+			resp, err := c.GetWithQueryParams(tt.ctx, tt.path, tt.query)
+			if tt.expectErrMessage != "" {
+				assert.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Regexp(t, tt.expectErrMessage, err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			}
+		})
+	}
+}
+
+func TestErrorChainPreserved(t *testing.T) {
+	c, err := NewDefaultClient(validTestBaseURL)
+	require.NoError(t, err)
+	httpmock.Activate()
+	defer httpmock.Deactivate()
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := c.Get(ctx, "/slow")
+		require.Error(t, err)
+		assert.Regexp(t, "request failed.*", err.Error())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("network error", func(t *testing.T) {
+		httpmock.RegisterNoResponder(httpmock.NewErrorResponder(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}))
+
+		_, err := c.Get(context.Background(), "/unreachable")
+		require.Error(t, err)
+		var netErr net.Error
+		assert.True(t, errors.As(err, &netErr))
+	})
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+//RoundTripFunc sends single request and returns its response, it's what DefaultClient methods call to reach the API
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+//RoundTripMiddleware wraps RoundTripFunc with extra behaviour, it may modify the request before calling next
+//or the response returned by it
+type RoundTripMiddleware func(next RoundTripFunc) RoundTripFunc
+
+//Logger is satisfied by *log.Logger and most of structured loggers' printf adapters
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+//RequestMetrics describes single request sent by DefaultClient, StatusCode is 0 when no response was received
+type RequestMetrics struct {
+	Method     string
+	Host       string
+	Path       string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+//chain wraps send with middlewares, the first one is the outermost i.e. it sees the request first
+func chain(send RoundTripFunc, middlewares ...RoundTripMiddleware) RoundTripFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		send = middlewares[i](send)
+	}
+	return send
+}
+
+//Headers sets given headers on every request
+func Headers(h http.Header) RoundTripMiddleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			for k, v := range h {
+				req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+			}
+			return next(req)
+		}
+	}
+}
+
+//UserAgent sets User-Agent header on every request
+func UserAgent(ua string) RoundTripMiddleware {
+	return Headers(http.Header{"User-Agent": []string{ua}})
+}
+
+//RequestID sets header to random UUID unless request already carries one, so all attempts of a request share it
+func RequestID(header string) RoundTripMiddleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) == "" {
+				req.Header.Set(header, uuid.New().String())
+			}
+			return next(req)
+		}
+	}
+}
+
+//Logging logs method, URL, outcome and duration of every request
+func Logging(l Logger) RoundTripMiddleware {
+	return Metrics(func(m RequestMetrics) {
+		if m.Err != nil {
+			l.Printf("%s %s%s failed after %s: %v", m.Method, m.Host, m.Path, m.Duration, m.Err)
+			return
+		}
+		l.Printf("%s %s%s %d in %s", m.Method, m.Host, m.Path, m.StatusCode, m.Duration)
+	})
+}
+
+//Metrics calls observe after every request, e.g. to feed latency histograms and status code counters
+func Metrics(observe func(m RequestMetrics)) RoundTripMiddleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			m := RequestMetrics{
+				Method:   req.Method,
+				Host:     req.URL.Host,
+				Path:     req.URL.Path,
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			if resp != nil {
+				m.StatusCode = resp.StatusCode
+			}
+			observe(m)
+			return resp, err
+		}
+	}
+}
+
+//Retry repeats failed requests according to the policy, it's what WithRetryPolicy uses
+func Retry(policy RetryPolicy) RoundTripMiddleware {
+	return policy.retry
+}
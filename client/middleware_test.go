@@ -0,0 +1,106 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log"
+	"net/http"
+	"testing"
+)
+
+func TestWithMiddleware_Order(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusOK, ""))
+
+	var calls []string
+	layer := func(name string) RoundTripMiddleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(req)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+
+	c, err := NewDefaultClient(validTestBaseURL, WithMiddleware(layer("first"), layer("second")), WithMiddleware(layer("third")))
+	require.NoError(t, err)
+	_, err = c.Get(context.Background(), "/v1/organisation/accounts")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first before", "second before", "third before", "third after", "second after", "first after"}, calls)
+}
+
+func TestBuiltInMiddlewares(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var requests []*http.Request
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		if len(requests) == 1 {
+			return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
+		}
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	var logs bytes.Buffer
+	var metrics []RequestMetrics
+	c, err := NewDefaultClient(validTestBaseURL,
+		WithRetryPolicy(testRetryPolicy()),
+		WithMiddleware(
+			Headers(http.Header{"x-team": []string{"payments"}}),
+			UserAgent("form-client/1.0"),
+			RequestID("X-Request-Id"),
+			Logging(log.New(&logs, "", 0)),
+			Metrics(func(m RequestMetrics) { metrics = append(metrics, m) }),
+		),
+	)
+	require.NoError(t, err)
+	_, err = c.Get(context.Background(), "/v1/organisation/accounts")
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	for _, req := range requests {
+		assert.Equal(t, "payments", req.Header.Get("X-Team"))
+		assert.Equal(t, "form-client/1.0", req.Header.Get("User-Agent"))
+	}
+	assert.NotEmpty(t, requests[0].Header.Get("X-Request-Id"))
+	assert.Equal(t, requests[0].Header.Get("X-Request-Id"), requests[1].Header.Get("X-Request-Id"))
+
+	require.Len(t, metrics, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, metrics[0].StatusCode)
+	assert.Equal(t, http.StatusOK, metrics[1].StatusCode)
+	assert.Equal(t, RequestMetrics{Method: http.MethodGet, Host: validTestBaseURL, Path: "/v1/organisation/accounts", StatusCode: http.StatusOK},
+		RequestMetrics{Method: metrics[1].Method, Host: metrics[1].Host, Path: metrics[1].Path, StatusCode: metrics[1].StatusCode})
+
+	assert.Regexp(t, "GET test.com/v1/organisation/accounts 503 in .*\nGET test.com/v1/organisation/accounts 200 in .*\n", logs.String())
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusBadGateway, ""))
+
+	var attempts int
+	count := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return next(req)
+		}
+	}
+
+	c, err := NewDefaultClient(validTestBaseURL, WithMiddleware(count, Retry(testRetryPolicy())))
+	require.NoError(t, err)
+	resp, err := c.Get(context.Background(), "/v1/organisation/accounts")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
@@ -1,6 +1,12 @@
 package client
 
-import "net/http"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"net/http"
+	"os"
+)
 
 //Option definition for DefaultClient
 type Option func(p *Config)
@@ -14,3 +20,114 @@ func WithHTTPClient(c *http.Client) Option {
 func WithHTTPS() Option {
 	return func(p *Config) { p.scheme = "https" }
 }
+
+//WithRetryPolicy enables retrying requests that timed out, failed to connect or got retryable status code,
+//POST and PATCH requests are retried only when policy sets IdempotencyKeyHeader
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(p *Config) { p.retry = &policy }
+}
+
+//WithRateLimit limits rate and concurrency of requests sent by the client, it's safe to share the client between goroutines.
+//Rate is halved whenever API responds with 429 and restored gradually with following successful responses
+func WithRateLimit(limit RateLimit) Option {
+	return func(p *Config) { p.limiter = newRateLimiter(limit) }
+}
+
+//WithCircuitBreaker makes the client fail fast with ErrCircuitOpen while host it sends requests to keeps failing,
+//every host has its own circuit
+func WithCircuitBreaker(cb CircuitBreaker) Option {
+	return func(p *Config) { p.breakers = newBreakerGroup(cb) }
+}
+
+//WithMiddleware adds middlewares around sending of every request, the first one given is the outermost.
+//Middlewares are called for each attempt made according to retry policy, after rate limiter and circuit breaker let the request through
+func WithMiddleware(middlewares ...RoundTripMiddleware) Option {
+	return func(p *Config) { p.middlewares = append(p.middlewares, middlewares...) }
+}
+
+//WithSigner signs every request with HTTP signature, it's required by the real accounts API
+func WithSigner(s *Signer) Option {
+	return func(p *Config) { p.signer = s }
+}
+
+//WithTokenSource authorises every request with token from the source, request rejected with 401 is repeated once with refreshed token
+func WithTokenSource(ts TokenSource) Option {
+	return func(p *Config) { p.tokens = ts }
+}
+
+//WithClientCertificate authenticates the client with certificate and key read from PEM files, files are read again
+//for new connections whenever they change on disk. Like all TLS options it implies HTTPS
+func WithClientCertificate(certFile, keyFile string) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		r, err := newCertReloader(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		t.certificate = r.GetClientCertificate
+		return nil
+	})
+}
+
+//WithClientCertificatePEM authenticates the client with PEM encoded certificate and key
+func WithClientCertificatePEM(certPEM, keyPEM []byte) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return errors.Wrap(err, "failed to load client certificate")
+		}
+		t.certificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &cert, nil }
+		return nil
+	})
+}
+
+//WithRootCAs sets certificate authorities API server certificate is verified with instead of system ones
+func WithRootCAs(pool *x509.CertPool) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		t.rootCAs = pool
+		return nil
+	})
+}
+
+//WithRootCAsPEM sets PEM encoded certificate authorities API server certificate is verified with instead of system ones
+func WithRootCAsPEM(caPEM []byte) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return errors.New("no root CA certificates found in PEM")
+		}
+		t.rootCAs = pool
+		return nil
+	})
+}
+
+//WithRootCAFile sets certificate authorities read from PEM file API server certificate is verified with instead of system ones
+func WithRootCAFile(path string) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		caPEM, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read root CA file: %s", path)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return errors.Errorf("no root CA certificates found in %s", path)
+		}
+		t.rootCAs = pool
+		return nil
+	})
+}
+
+//WithMinTLSVersion sets minimum TLS version e.g. tls.VersionTLS12
+func WithMinTLSVersion(version uint16) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		t.minVersion = version
+		return nil
+	})
+}
+
+//WithServerName overrides name API server certificate is verified against, by default it's host of baseURL
+func WithServerName(name string) Option {
+	return tlsOption(func(p *Config, t *tlsConfig) error {
+		t.serverName = name
+		return nil
+	})
+}
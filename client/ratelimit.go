@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//rate never drops below maxRate/minRateDivisor regardless of how many 429 responses were observed
+const minRateDivisor = 16
+
+//RateLimit describes how many requests DefaultClient is allowed to send
+type RateLimit struct {
+	//RequestsPerSecond is the rate tokens are added to the bucket with, 0 disables the bucket
+	RequestsPerSecond float64
+	//Burst is the size of the bucket i.e. how many requests can be sent at once after a quiet period
+	Burst int
+	//MaxInFlight caps the number of requests waiting for response at the same time, 0 means no cap
+	MaxInFlight int
+}
+
+//RateLimitStats are counters describing how the rate limiter behaved since the client was created
+type RateLimitStats struct {
+	//Sent is the number of requests that were let through
+	Sent uint64
+	//Delayed is the number of requests that had to wait for a token or in-flight slot
+	Delayed uint64
+	//Cancelled is the number of requests which context was done before they could be sent
+	Cancelled uint64
+	//Throttled is the number of 429 responses observed
+	Throttled uint64
+	//InFlight is the number of requests currently waiting for response
+	InFlight int64
+	//CurrentRate is requests per second allowed at the moment, lower than configured one after 429 responses
+	CurrentRate float64
+}
+
+//rateLimiter is a token bucket with in-flight semaphore, it halves the rate on 429 responses
+//and restores it gradually with every successful one
+type rateLimiter struct {
+	//counters are kept first to be 64-bit aligned for atomic operations on 32-bit platforms
+	sent      uint64
+	delayed   uint64
+	cancelled uint64
+	throttled uint64
+	inFlight  int64
+
+	mu      sync.Mutex
+	maxRate float64
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	slots   chan struct{}
+}
+
+func newRateLimiter(l RateLimit) *rateLimiter {
+	r := &rateLimiter{
+		maxRate: l.RequestsPerSecond,
+		rate:    l.RequestsPerSecond,
+		burst:   float64(l.Burst),
+		last:    time.Now(),
+	}
+	if r.burst < 1 {
+		r.burst = 1
+	}
+	r.tokens = r.burst
+	if l.MaxInFlight > 0 {
+		r.slots = make(chan struct{}, l.MaxInFlight)
+	}
+	return r
+}
+
+//limit makes next wait for its turn before sending the request
+func (r *rateLimiter) limit(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		if err := r.acquire(ctx); err != nil {
+			atomic.AddUint64(&r.cancelled, 1)
+			return nil, err
+		}
+		atomic.AddUint64(&r.sent, 1)
+		atomic.AddInt64(&r.inFlight, 1)
+		resp, err := next(req)
+		atomic.AddInt64(&r.inFlight, -1)
+		r.release()
+
+		if err == nil {
+			r.observe(resp.StatusCode)
+		}
+		return resp, err
+	}
+}
+
+func (r *rateLimiter) acquire(ctx context.Context) error {
+	delayed := false
+	if d := r.reserve(); d > 0 {
+		delayed = true
+		if err := wait(ctx, d); err != nil {
+			r.unreserve()
+			return err
+		}
+	}
+	if r.slots != nil {
+		select {
+		case r.slots <- struct{}{}:
+		default:
+			delayed = true
+			select {
+			case r.slots <- struct{}{}:
+			case <-ctx.Done():
+				r.unreserve()
+				return ctx.Err()
+			}
+		}
+	}
+	if delayed {
+		atomic.AddUint64(&r.delayed, 1)
+	}
+	return nil
+}
+
+func (r *rateLimiter) release() {
+	if r.slots != nil {
+		<-r.slots
+	}
+}
+
+//reserve takes a token from the bucket and returns how long caller has to wait for it to become available
+func (r *rateLimiter) reserve() time.Duration {
+	if r.maxRate <= 0 {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
+
+//unreserve gives the token back when caller gave up waiting for it or for in-flight slot
+func (r *rateLimiter) unreserve() {
+	if r.maxRate <= 0 {
+		return
+	}
+	r.mu.Lock()
+	r.tokens++
+	r.mu.Unlock()
+}
+
+//observe halves the rate when API reported too many requests and increases it by 1/16 of configured rate otherwise
+func (r *rateLimiter) observe(statusCode int) {
+	if statusCode == http.StatusTooManyRequests {
+		atomic.AddUint64(&r.throttled, 1)
+	}
+	if r.maxRate <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if statusCode == http.StatusTooManyRequests {
+		r.rate /= 2
+		if min := r.maxRate / minRateDivisor; r.rate < min {
+			r.rate = min
+		}
+		return
+	}
+	r.rate += r.maxRate / minRateDivisor
+	if r.rate > r.maxRate {
+		r.rate = r.maxRate
+	}
+}
+
+func (r *rateLimiter) stats() RateLimitStats {
+	r.mu.Lock()
+	rate := r.rate
+	r.mu.Unlock()
+	return RateLimitStats{
+		Sent:        atomic.LoadUint64(&r.sent),
+		Delayed:     atomic.LoadUint64(&r.delayed),
+		Cancelled:   atomic.LoadUint64(&r.cancelled),
+		Throttled:   atomic.LoadUint64(&r.throttled),
+		InFlight:    atomic.LoadInt64(&r.inFlight),
+		CurrentRate: rate,
+	}
+}
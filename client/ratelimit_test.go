@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusOK, ""))
+
+	c, err := NewDefaultClient(validTestBaseURL, WithRateLimit(RateLimit{RequestsPerSecond: 50, Burst: 2}))
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := c.Get(context.Background(), "/v1/organisation/accounts")
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(35*time.Millisecond))
+
+	stats := c.RateLimitStats()
+	assert.Equal(t, uint64(4), stats.Sent)
+	assert.Equal(t, uint64(2), stats.Delayed)
+	assert.Equal(t, int64(0), stats.InFlight)
+}
+
+func TestRateLimit_MaxInFlight(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var inFlight, maxInFlight int64
+	var mu sync.Mutex
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		mu.Lock()
+		if n > maxInFlight {
+			maxInFlight = n
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt64(&inFlight, -1)
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	c, err := NewDefaultClient(validTestBaseURL, WithRateLimit(RateLimit{MaxInFlight: 2}))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(context.Background(), "/v1/organisation/accounts")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight, int64(2))
+	assert.Equal(t, uint64(10), c.RateLimitStats().Sent)
+}
+
+func TestRateLimit_ContextDone(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusOK, ""))
+
+	c, err := NewDefaultClient(validTestBaseURL, WithRateLimit(RateLimit{RequestsPerSecond: 1, Burst: 1}))
+	require.NoError(t, err)
+
+	_, err = c.Get(context.Background(), "/v1/organisation/accounts")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.Get(ctx, "/v1/organisation/accounts")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	stats := c.RateLimitStats()
+	assert.Equal(t, uint64(1), stats.Sent)
+	assert.Equal(t, uint64(1), stats.Cancelled)
+}
+
+func TestRateLimit_ContextDoneWaitingForSlot(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	unblock := make(chan struct{})
+	httpmock.RegisterResponder(http.MethodGet, "http://"+validTestBaseURL+"/blocked", func(req *http.Request) (*http.Response, error) {
+		<-unblock
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+	httpmock.RegisterNoResponder(httpmock.NewStringResponder(http.StatusOK, ""))
+
+	c, err := NewDefaultClient(validTestBaseURL, WithRateLimit(RateLimit{RequestsPerSecond: 0.1, Burst: 2, MaxInFlight: 1}))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := c.Get(context.Background(), "/blocked")
+		assert.NoError(t, err)
+	}()
+	require.Eventually(t, func() bool { return c.RateLimitStats().InFlight == 1 }, time.Second, time.Millisecond)
+
+	//request gets the token but gives up waiting for in-flight slot
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.Get(ctx, "/v1/organisation/accounts")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	close(unblock)
+	<-done
+
+	//token of the cancelled request is back in the bucket so this one doesn't wait 10s for a new one
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = c.Get(ctx, "/v1/organisation/accounts")
+	require.NoError(t, err)
+
+	stats := c.RateLimitStats()
+	assert.Equal(t, uint64(2), stats.Sent)
+	assert.Equal(t, uint64(1), stats.Cancelled)
+}
+
+func TestRateLimit_AdaptiveSlowdown(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	status := http.StatusTooManyRequests
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(status, ""), nil
+	})
+
+	c, err := NewDefaultClient(validTestBaseURL, WithRateLimit(RateLimit{RequestsPerSecond: 1000, Burst: 30}))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err := c.Get(context.Background(), "/v1/organisation/accounts")
+		require.NoError(t, err)
+	}
+	stats := c.RateLimitStats()
+	assert.Equal(t, uint64(10), stats.Throttled)
+	assert.Equal(t, 1000.0/minRateDivisor, stats.CurrentRate)
+
+	status = http.StatusOK
+	for i := 0; i < minRateDivisor; i++ {
+		_, err := c.Get(context.Background(), "/v1/organisation/accounts")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1000.0, c.RateLimitStats().CurrentRate)
+}
+
+func TestRateLimitStats_Disabled(t *testing.T) {
+	c, err := NewDefaultClient(validTestBaseURL)
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitStats{}, c.RateLimitStats())
+}
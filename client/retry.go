@@ -0,0 +1,171 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"github.com/Gobonoid/form/internal/neterr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RetryPolicy describes when and how often failed requests are repeated
+type RetryPolicy struct {
+	//MaxAttempts is the total number of attempts including the first one
+	MaxAttempts int
+	//BaseDelay is the delay before the first retry, it doubles with every following one
+	BaseDelay time.Duration
+	//MaxDelay caps delay between attempts, both exponential one and Retry-After sent by the API
+	MaxDelay time.Duration
+	//Jitter is a fraction (0-1) of the delay that is randomised to spread retries of concurrent clients
+	Jitter float64
+	//RetryableStatus lists response status codes worth repeating the request for
+	RetryableStatus map[int]bool
+	//IdempotencyKeyHeader enables retries of POST and PATCH requests, unique key is sent in this header
+	//and kept the same across all attempts so the API can recognise repeated request
+	IdempotencyKeyHeader string
+}
+
+//DefaultRetryPolicy retries timeouts, refused or reset connections and 429, 502, 503, 504 responses up to 3 attempts
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.2,
+		RetryableStatus: map[int]bool{
+			http.StatusTooManyRequests:    true,
+			http.StatusBadGateway:         true,
+			http.StatusServiceUnavailable: true,
+			http.StatusGatewayTimeout:     true,
+		},
+	}
+}
+
+//retry repeats requests next failed with according to the policy
+func (p RetryPolicy) retry(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if p.MaxAttempts <= 1 || !p.canRetry(req) {
+			return next(req)
+		}
+		if err := rewindable(req); err != nil {
+			return nil, err
+		}
+		if p.IdempotencyKeyHeader != "" && req.Header.Get(p.IdempotencyKeyHeader) == "" {
+			req.Header.Set(p.IdempotencyKeyHeader, uuid.New().String())
+		}
+
+		ctx := req.Context()
+		for attempt := 1; ; attempt++ {
+			resp, err := next(req)
+			if attempt >= p.MaxAttempts || !p.shouldRetry(ctx, resp, err) {
+				return resp, err
+			}
+			delay := p.delay(attempt, resp)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return resp, err
+			}
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+			if err := wait(ctx, delay); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to rewind request body")
+				}
+				req.Body = body
+			}
+		}
+	}
+}
+
+//canRetry allows retries of idempotent methods and of others only when idempotency key is being sent
+func (p RetryPolicy) canRetry(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return p.IdempotencyKeyHeader != ""
+}
+
+func (p RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return neterr.Temporary(err)
+	}
+	return p.RetryableStatus[resp.StatusCode]
+}
+
+//delay returns exponential backoff for given attempt unless the API asked to wait longer with Retry-After,
+//both are capped by MaxDelay
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	d := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(attempt-1)))
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok && after > d {
+			d = after
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+//retryAfter parses Retry-After header given either in seconds or as HTTP date
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+//rewindable makes sure request body can be sent again by buffering it when request doesn't know how to recreate it
+func rewindable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+	b, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to buffer request body")
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
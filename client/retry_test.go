@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.BaseDelay = time.Millisecond
+	p.MaxDelay = 5 * time.Millisecond
+	return p
+}
+
+func TestRetryPolicy(t *testing.T) {
+	url := "http://" + validTestBaseURL + "/v1/organisation/accounts"
+	withKey := testRetryPolicy()
+	withKey.IdempotencyKeyHeader = "Idempotency-Key"
+
+	tests := []struct {
+		name           string
+		policy         RetryPolicy
+		method         string
+		statuses       []int
+		transportErr   error
+		expectStatus   int
+		expectAttempts int
+		expectErr      bool
+	}{
+		{
+			name:           "retries until success",
+			policy:         testRetryPolicy(),
+			method:         http.MethodGet,
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectStatus:   http.StatusOK,
+			expectAttempts: 3,
+		},
+		{
+			name:           "returns last response when attempts are exhausted",
+			policy:         testRetryPolicy(),
+			method:         http.MethodGet,
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectStatus:   http.StatusServiceUnavailable,
+			expectAttempts: 3,
+		},
+		{
+			name:           "doesn't retry non retryable status",
+			policy:         testRetryPolicy(),
+			method:         http.MethodDelete,
+			statuses:       []int{http.StatusNotFound, http.StatusOK},
+			expectStatus:   http.StatusNotFound,
+			expectAttempts: 1,
+		},
+		{
+			name:           "doesn't retry POST without idempotency key",
+			policy:         testRetryPolicy(),
+			method:         http.MethodPost,
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusCreated},
+			expectStatus:   http.StatusServiceUnavailable,
+			expectAttempts: 1,
+		},
+		{
+			name:           "retries POST with idempotency key",
+			policy:         withKey,
+			method:         http.MethodPost,
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusCreated},
+			expectStatus:   http.StatusCreated,
+			expectAttempts: 2,
+		},
+		{
+			name:           "retries reset connection",
+			policy:         testRetryPolicy(),
+			method:         http.MethodGet,
+			transportErr:   &net.OpError{Op: "read", Net: "tcp", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}},
+			expectErr:      true,
+			expectAttempts: 3,
+		},
+		{
+			name:           "doesn't retry TLS errors",
+			policy:         testRetryPolicy(),
+			method:         http.MethodGet,
+			transportErr:   x509.UnknownAuthorityError{},
+			expectErr:      true,
+			expectAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			var bodies, keys []string
+			attempts := 0
+			httpmock.RegisterResponder(tt.method, url, func(req *http.Request) (*http.Response, error) {
+				attempts++
+				if req.Body != nil {
+					b, _ := io.ReadAll(req.Body)
+					bodies = append(bodies, string(b))
+				}
+				keys = append(keys, req.Header.Get("Idempotency-Key"))
+				if tt.transportErr != nil {
+					return nil, tt.transportErr
+				}
+				return httpmock.NewStringResponse(tt.statuses[attempts-1], ""), nil
+			})
+
+			c, err := NewDefaultClient(validTestBaseURL, WithRetryPolicy(tt.policy))
+			require.NoError(t, err)
+
+			var resp *http.Response
+			switch tt.method {
+			case http.MethodGet:
+				resp, err = c.Get(context.Background(), "/v1/organisation/accounts")
+			case http.MethodPost:
+				resp, err = c.Post(context.Background(), "/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+			case http.MethodDelete:
+				resp, err = c.DeleteWithQueryParams(context.Background(), "/v1/organisation/accounts", nil)
+			}
+
+			assert.Equal(t, tt.expectAttempts, attempts)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			if tt.method == http.MethodPost {
+				for i := range bodies {
+					assert.Equal(t, `{"data":{}}`, bodies[i])
+					assert.Equal(t, keys[0], keys[i])
+				}
+				assert.Equal(t, tt.policy.IdempotencyKeyHeader != "", keys[0] != "")
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_RespectsDeadline(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	attempts := 0
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		resp := httpmock.NewStringResponse(http.StatusTooManyRequests, "")
+		resp.Header.Set("Retry-After", "10")
+		return resp, nil
+	})
+
+	p := testRetryPolicy()
+	p.MaxDelay = time.Minute
+	c, err := NewDefaultClient(validTestBaseURL, WithRetryPolicy(p))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := c.Get(ctx, "/v1/organisation/accounts")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+	retryAfter := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{v}}}
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		resp    *http.Response
+		expect  time.Duration
+	}{
+		{name: "first retry", attempt: 1, expect: 100 * time.Millisecond},
+		{name: "exponential", attempt: 3, expect: 400 * time.Millisecond},
+		{name: "capped", attempt: 10, expect: 5 * time.Second},
+		{name: "retry after seconds", attempt: 1, resp: retryAfter("3"), expect: 3 * time.Second},
+		{name: "retry after is capped", attempt: 1, resp: retryAfter("3600"), expect: 5 * time.Second},
+		{name: "retry after shorter than backoff", attempt: 3, resp: retryAfter("0"), expect: 400 * time.Millisecond},
+		{name: "invalid retry after", attempt: 1, resp: retryAfter("soon"), expect: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, p.delay(tt.attempt, tt.resp))
+		})
+	}
+}
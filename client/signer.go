@@ -0,0 +1,297 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+//Signature algorithms supported by Signer and Verifier
+const (
+	AlgorithmRSASHA256 = "rsa-sha256"
+	AlgorithmEd25519   = "ed25519"
+)
+
+//signedHeaders are covered by every signature made by Signer
+const signedHeaders = "(request-target) host date digest"
+
+//defaultMaxSkew is how far Date header of the verified request can be from verifier's clock
+const defaultMaxSkew = 5 * time.Minute
+
+//ErrInvalidSignature is returned by Verifier when request signature can't be verified
+var ErrInvalidSignature = errors.New("invalid signature")
+
+//Signer signs requests with draft-cavage HTTP signature, it sets Date, Digest and Signature headers
+type Signer struct {
+	keyID     string
+	key       crypto.Signer
+	algorithm string
+	now       func() time.Time
+}
+
+//NewSigner creates Signer from PEM encoded RSA (PKCS1 or PKCS8) or Ed25519 (PKCS8) private key
+func NewSigner(keyID string, pemKey []byte) (*Signer, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("private key isn't PEM encoded")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, errors.Errorf("unsupported private key type %s", block.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+
+	s := &Signer{keyID: keyID, now: time.Now}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s.key, s.algorithm = k, AlgorithmRSASHA256
+	case ed25519.PrivateKey:
+		s.key, s.algorithm = k, AlgorithmEd25519
+	default:
+		return nil, errors.Errorf("unsupported private key %T", key)
+	}
+	return s, nil
+}
+
+//LoadSigner creates Signer from PEM file
+func LoadSigner(keyID string, path string) (*Signer, error) {
+	pemKey, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read private key: %s", path)
+	}
+	return NewSigner(keyID, pemKey)
+}
+
+//Sign sets Date, Digest and Signature headers of the request
+func (s *Signer) Sign(req *http.Request) error {
+	digest, err := bodyDigest(req)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Date", s.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("Digest", digest)
+
+	message := []byte(signingString(req, strings.Fields(signedHeaders)))
+	var signature []byte
+	switch s.algorithm {
+	case AlgorithmRSASHA256:
+		hashed := sha256.Sum256(message)
+		signature, err = s.key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	case AlgorithmEd25519:
+		signature, err = s.key.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to sign request")
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.keyID, s.algorithm, signedHeaders, base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+//Middleware signs every attempt of the request, it's what WithSigner uses
+func (s *Signer) Middleware() RoundTripMiddleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := s.Sign(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+//Verifier checks signatures made by Signer, it's meant to be used by fake servers in tests
+type Verifier struct {
+	keys map[string]crypto.PublicKey
+	//MaxSkew is how far Date header can be from the current time, 5 minutes by default
+	MaxSkew time.Duration
+	now     func() time.Time
+}
+
+//NewVerifier creates Verifier without any keys
+func NewVerifier() *Verifier {
+	return &Verifier{
+		keys:    map[string]crypto.PublicKey{},
+		MaxSkew: defaultMaxSkew,
+		now:     time.Now,
+	}
+}
+
+//AddKey registers PEM encoded public key (PKIX or PKCS1) under keyID
+func (v *Verifier) AddKey(keyID string, pemKey []byte) error {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return errors.New("public key isn't PEM encoded")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return errors.Errorf("unsupported public key type %s", block.Type)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to parse public key")
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return errors.Errorf("unsupported public key %T", key)
+	}
+	v.keys[keyID] = key
+	return nil
+}
+
+//Verify checks Signature, Digest and Date headers of the request, returned errors wrap ErrInvalidSignature
+func (v *Verifier) Verify(req *http.Request) error {
+	params, err := parseSignature(req.Header.Get("Signature"))
+	if err != nil {
+		return err
+	}
+	key, ok := v.keys[params["keyId"]]
+	if !ok {
+		return errors.Wrapf(ErrInvalidSignature, "unknown keyId %q", params["keyId"])
+	}
+	headers := strings.Fields(params["headers"])
+	for _, required := range strings.Fields(signedHeaders) {
+		if !contains(headers, required) {
+			return errors.Wrapf(ErrInvalidSignature, "%s isn't signed", required)
+		}
+	}
+
+	digest, err := bodyDigest(req)
+	if err != nil {
+		return err
+	}
+	if req.Header.Get("Digest") != digest {
+		return errors.Wrap(ErrInvalidSignature, "digest doesn't match body")
+	}
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, "date isn't valid")
+	}
+	if skew := v.now().Sub(date); skew > v.MaxSkew || -skew > v.MaxSkew {
+		return errors.Wrap(ErrInvalidSignature, "date is too far from current time")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, "signature isn't base64 encoded")
+	}
+	message := []byte(signingString(req, headers))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if params["algorithm"] != AlgorithmRSASHA256 {
+			return errors.Wrapf(ErrInvalidSignature, "algorithm %q doesn't match the key", params["algorithm"])
+		}
+		hashed := sha256.Sum256(message)
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], signature) != nil {
+			return errors.Wrap(ErrInvalidSignature, "signature doesn't match")
+		}
+	case ed25519.PublicKey:
+		if params["algorithm"] != AlgorithmEd25519 {
+			return errors.Wrapf(ErrInvalidSignature, "algorithm %q doesn't match the key", params["algorithm"])
+		}
+		if !ed25519.Verify(k, message, signature) {
+			return errors.Wrap(ErrInvalidSignature, "signature doesn't match")
+		}
+	}
+	return nil
+}
+
+//signingString builds string covered by the signature out of given headers
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		var value string
+		switch h {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		default:
+			value = strings.Join(req.Header.Values(h), ", ")
+		}
+		lines = append(lines, h+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+//bodyDigest returns Digest header value for the request body, the body is left intact to be sent or read again
+func bodyDigest(req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read request body")
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if req.GetBody == nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+	}
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+//parseSignature parses Signature header parameters e.g. keyId="key",algorithm="ed25519"
+func parseSignature(header string) (map[string]string, error) {
+	if header == "" {
+		return nil, errors.Wrap(ErrInvalidSignature, "signature is missing")
+	}
+	params := map[string]string{}
+	for _, param := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 || len(kv[1]) < 2 || !strings.HasPrefix(kv[1], `"`) || !strings.HasSuffix(kv[1], `"`) {
+			return nil, errors.Wrapf(ErrInvalidSignature, "malformed signature parameter %q", param)
+		}
+		params[kv[0]] = kv[1][1 : len(kv[1])-1]
+	}
+	for _, required := range []string{"keyId", "algorithm", "headers", "signature"} {
+		if params[required] == "" {
+			return nil, errors.Wrapf(ErrInvalidSignature, "signature %s is missing", required)
+		}
+	}
+	return params, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
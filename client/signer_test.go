@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/jarcoal/httpmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	name    string
+	private []byte
+	public  []byte
+}
+
+func testKeys(t *testing.T) []testKey {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPKCS8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPKIX, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+
+	encode := func(typ string, b []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b})
+	}
+	return []testKey{
+		{
+			name:    "rsa pkcs1",
+			private: encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			public:  encode("RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)),
+		},
+		{
+			name:    "rsa pkcs8",
+			private: encode("PRIVATE KEY", rsaPKCS8),
+			public:  encode("PUBLIC KEY", rsaPublic),
+		},
+		{
+			name:    "ed25519",
+			private: encode("PRIVATE KEY", edPKCS8),
+			public:  encode("PUBLIC KEY", edPKIX),
+		},
+	}
+}
+
+func TestSigner(t *testing.T) {
+	for _, key := range testKeys(t) {
+		t.Run(key.name, func(t *testing.T) {
+			s, err := NewSigner("test-key", key.private)
+			require.NoError(t, err)
+			v := NewVerifier()
+			require.NoError(t, v.AddKey("test-key", key.public))
+
+			req, err := http.NewRequest(http.MethodPost, "http://test.com/v1/organisation/accounts?a=b", strings.NewReader(`{"data":{}}`))
+			require.NoError(t, err)
+			require.NoError(t, s.Sign(req))
+
+			assert.Equal(t, "SHA-256=f7nRZtGhW84LnwhfOBiUb9kpfkUTpKA0oM63SSkrTA0=", req.Header.Get("Digest"))
+			assert.Regexp(t, `^keyId="test-key",algorithm="(rsa-sha256|ed25519)",headers="\(request-target\) host date digest",signature="[A-Za-z0-9+/=]+"$`,
+				req.Header.Get("Signature"))
+			assert.NoError(t, v.Verify(req))
+
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, `{"data":{}}`, string(body), "body has to be left intact")
+		})
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	key := testKeys(t)[2]
+	s, err := NewSigner("test-key", key.private)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		tamper           func(req *http.Request, v *Verifier)
+		expectErrMessage string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:             "unsigned",
+			tamper:           func(req *http.Request, v *Verifier) { req.Header.Del("Signature") },
+			expectErrMessage: "signature is missing",
+		},
+		{
+			name: "body changed",
+			tamper: func(req *http.Request, v *Verifier) {
+				req.Body = io.NopCloser(strings.NewReader(`{"data":{"id":"x"}}`))
+			},
+			expectErrMessage: "digest doesn't match body",
+		},
+		{
+			name:             "path changed",
+			tamper:           func(req *http.Request, v *Verifier) { req.URL.Path = "/v1/organisation/other" },
+			expectErrMessage: "signature doesn't match",
+		},
+		{
+			name:             "unknown key",
+			tamper:           func(req *http.Request, v *Verifier) { v.keys = map[string]crypto.PublicKey{} },
+			expectErrMessage: `unknown keyId "test-key"`,
+		},
+		{
+			name: "stale date",
+			tamper: func(req *http.Request, v *Verifier) {
+				v.now = func() time.Time { return time.Now().Add(time.Hour) }
+			},
+			expectErrMessage: "date is too far from current time",
+		},
+		{
+			name: "digest not signed",
+			tamper: func(req *http.Request, v *Verifier) {
+				req.Header.Set("Signature", strings.Replace(req.Header.Get("Signature"), " digest", "", 1))
+			},
+			expectErrMessage: "digest isn't signed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier()
+			require.NoError(t, v.AddKey("test-key", key.public))
+			req, err := http.NewRequest(http.MethodPost, "http://test.com/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+			require.NoError(t, err)
+			require.NoError(t, s.Sign(req))
+			if tt.tamper != nil {
+				tt.tamper(req, v)
+			}
+
+			err = v.Verify(req)
+			if tt.expectErrMessage != "" {
+				assert.True(t, errors.Is(err, ErrInvalidSignature))
+				assert.Regexp(t, tt.expectErrMessage, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewSigner_InvalidKey(t *testing.T) {
+	_, err := NewSigner("test-key", []byte("not a key"))
+	assert.EqualError(t, err, "private key isn't PEM encoded")
+
+	_, err = NewSigner("test-key", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte{1}}))
+	assert.EqualError(t, err, "unsupported private key type EC PRIVATE KEY")
+
+	_, err = LoadSigner("test-key", filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Regexp(t, "failed to read private key.*", err.Error())
+}
+
+func TestWithSigner(t *testing.T) {
+	key := testKeys(t)[0]
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, key.private, 0600))
+	s, err := LoadSigner("test-key", path)
+	require.NoError(t, err)
+	v := NewVerifier()
+	require.NoError(t, v.AddKey("test-key", key.public))
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		if err := v.Verify(req); err != nil {
+			return httpmock.NewStringResponse(http.StatusUnauthorized, err.Error()), nil
+		}
+		return httpmock.NewStringResponse(http.StatusCreated, ""), nil
+	})
+
+	c, err := NewDefaultClient(validTestBaseURL, WithSigner(s), WithMiddleware(Headers(http.Header{"X-Team": []string{"payments"}})))
+	require.NoError(t, err)
+	resp, err := c.Post(context.Background(), "/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+//tlsConfig collects TLS options until http.Client is built
+type tlsConfig struct {
+	certificate func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+	rootCAs     *x509.CertPool
+	minVersion  uint16
+	serverName  string
+}
+
+//tlsOption applies f to TLS configuration and switches scheme to HTTPS
+func tlsOption(f func(p *Config, t *tlsConfig) error) Option {
+	return func(p *Config) {
+		if p.tls == nil {
+			p.tls = &tlsConfig{}
+		}
+		p.scheme = "https"
+		if err := f(p, p.tls); err != nil && p.err == nil {
+			p.err = err
+		}
+	}
+}
+
+//build returns copy of c with transport configured according to TLS options, c itself is never modified
+func (t *tlsConfig) build(c *http.Client) (*http.Client, error) {
+	var transport *http.Transport
+	switch rt := c.Transport.(type) {
+	case nil:
+		if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport = dt.Clone()
+		} else {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+	case *http.Transport:
+		transport = rt.Clone()
+	default:
+		return nil, errors.Errorf("TLS options require http.Client with *http.Transport, got %T", rt)
+	}
+
+	conf := transport.TLSClientConfig
+	if conf == nil {
+		conf = &tls.Config{}
+	}
+	if t.certificate != nil {
+		conf.GetClientCertificate = t.certificate
+	}
+	if t.rootCAs != nil {
+		conf.RootCAs = t.rootCAs
+	}
+	if t.minVersion != 0 {
+		conf.MinVersion = t.minVersion
+	}
+	if t.serverName != "" {
+		conf.ServerName = t.serverName
+	}
+	transport.TLSClientConfig = conf
+
+	built := *c
+	built.Transport = transport
+	return &built, nil
+}
+
+//certReloader loads client certificate from files and reloads it whenever any of them is modified
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//GetClientCertificate as in tls.Config, when reload fails previously loaded certificate keeps being used
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.reloadIfModified()
+	return r.cert, nil
+}
+
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reloadIfModified()
+}
+
+func (r *certReloader) reloadIfModified() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat client certificate: %s", r.certFile)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat client key: %s", r.keyFile)
+	}
+	if r.cert != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load client certificate")
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return nil
+}
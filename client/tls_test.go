@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key signed by the CA
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage, dnsNames []string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// newMTLSServer starts server requiring client certificates signed by ca, it responds with client certificate CN
+func newMTLSServer(t *testing.T, ca *testCA, dnsNames []string) *httptest.Server {
+	certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, dnsNames)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// baseURL of the server, IP address with port can't be parsed as baseURL
+func baseURL(srv *httptest.Server) string {
+	return strings.Replace(srv.URL, "https://127.0.0.1", "localhost", 1)
+}
+
+func clientCN(t *testing.T, c *DefaultClient) string {
+	resp, err := c.Get(context.Background(), "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestTLSOptions(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newMTLSServer(t, ca, []string{"localhost"})
+	certPEM, keyPEM := ca.issue(t, "client-pem", x509.ExtKeyUsageClientAuth, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+	tests := []struct {
+		name             string
+		options          []Option
+		expectErrMessage string
+		expectCN         string
+	}{
+		{
+			name:     "PEM certificate and CA",
+			options:  []Option{WithClientCertificatePEM(certPEM, keyPEM), WithRootCAsPEM(ca.pem), WithMinTLSVersion(tls.VersionTLS12)},
+			expectCN: "client-pem",
+		},
+		{
+			name:     "CA pool",
+			options:  []Option{WithClientCertificatePEM(certPEM, keyPEM), WithRootCAs(pool)},
+			expectCN: "client-pem",
+		},
+		{
+			name:     "CA file",
+			options:  []Option{WithClientCertificatePEM(certPEM, keyPEM), WithRootCAFile(caFile)},
+			expectCN: "client-pem",
+		},
+		{
+			name:             "invalid certificate",
+			options:          []Option{WithClientCertificatePEM([]byte("cert"), keyPEM)},
+			expectErrMessage: "failed to load client certificate.*",
+		},
+		{
+			name:             "invalid CA",
+			options:          []Option{WithRootCAsPEM([]byte("ca"))},
+			expectErrMessage: "no root CA certificates found in PEM",
+		},
+		{
+			name:             "missing CA file",
+			options:          []Option{WithRootCAFile(filepath.Join(t.TempDir(), "missing.pem"))},
+			expectErrMessage: "failed to read root CA file.*",
+		},
+		{
+			name:             "custom transport",
+			options:          []Option{WithHTTPClient(&http.Client{Transport: stubTransport{}}), WithRootCAs(pool)},
+			expectErrMessage: "TLS options require http.Client with \\*http.Transport.*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewDefaultClient(baseURL(srv), tt.options...)
+			if tt.expectErrMessage != "" {
+				assert.Error(t, err)
+				assert.Nil(t, c)
+				assert.Regexp(t, tt.expectErrMessage, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https", c.conf.scheme)
+			assert.Equal(t, tt.expectCN, clientCN(t, c))
+		})
+	}
+}
+
+func TestWithServerName(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newMTLSServer(t, ca, []string{"accounts.test"})
+	certPEM, keyPEM := ca.issue(t, "client", x509.ExtKeyUsageClientAuth, nil)
+
+	c, err := NewDefaultClient(baseURL(srv), WithClientCertificatePEM(certPEM, keyPEM), WithRootCAsPEM(ca.pem))
+	require.NoError(t, err)
+	_, err = c.Get(context.Background(), "/")
+	assert.Regexp(t, "certificate is valid for accounts.test, not localhost", err.Error())
+
+	c, err = NewDefaultClient(baseURL(srv), WithClientCertificatePEM(certPEM, keyPEM), WithRootCAsPEM(ca.pem), WithServerName("accounts.test"))
+	require.NoError(t, err)
+	assert.Equal(t, "client", clientCN(t, c))
+}
+
+func TestTLSOptions_DoesNotModifyHTTPClient(t *testing.T) {
+	ca := newTestCA(t)
+	transport := &http.Transport{}
+	hc := &http.Client{Transport: transport, Timeout: time.Second}
+
+	c, err := NewDefaultClient(validTestBaseURL, WithHTTPClient(hc), WithRootCAsPEM(ca.pem))
+	require.NoError(t, err)
+
+	if transport.TLSClientConfig != nil {
+		assert.Nil(t, transport.TLSClientConfig.RootCAs)
+	}
+	assert.NotSame(t, hc, c.conf.c)
+	assert.Equal(t, time.Second, c.conf.c.Timeout)
+	assert.NotNil(t, c.conf.c.Transport.(*http.Transport).TLSClientConfig.RootCAs)
+}
+
+func TestWithClientCertificate_Reload(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newMTLSServer(t, ca, []string{"localhost"})
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	write := func(cn string, mod time.Time) {
+		certPEM, keyPEM := ca.issue(t, cn, x509.ExtKeyUsageClientAuth, nil)
+		require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+		require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+		require.NoError(t, os.Chtimes(certFile, mod, mod))
+		require.NoError(t, os.Chtimes(keyFile, mod, mod))
+	}
+	write("client-1", time.Now().Add(-time.Minute))
+
+	c, err := NewDefaultClient(baseURL(srv), WithClientCertificate(certFile, keyFile), WithRootCAsPEM(ca.pem))
+	require.NoError(t, err)
+	assert.Equal(t, "client-1", clientCN(t, c))
+
+	write("client-2", time.Now())
+	srv.CloseClientConnections()
+	assert.Equal(t, "client-2", clientCN(t, c))
+
+	//broken files don't break the client, previous certificate is used until they're fixed
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+	srv.CloseClientConnections()
+	assert.Equal(t, "client-2", clientCN(t, c))
+
+	_, err = NewDefaultClient(validTestBaseURL, WithClientCertificate(filepath.Join(dir, "missing.pem"), keyFile))
+	assert.Regexp(t, "failed to stat client certificate.*", err.Error())
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//defaultExpiryLeeway is how long before expiry cached token is refreshed
+const defaultExpiryLeeway = 30 * time.Second
+
+//Token is an OAuth2 access token
+type Token struct {
+	AccessToken string
+	TokenType   string
+	Expiry      time.Time
+}
+
+//authorization returns value of Authorization header for the token
+func (t *Token) authorization() string {
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer " + t.AccessToken
+	}
+	return t.TokenType + " " + t.AccessToken
+}
+
+//TokenSource provides tokens requests are authorised with
+type TokenSource interface {
+	//Token returns valid token, it may be cached
+	Token(ctx context.Context) (*Token, error)
+	//Refresh returns new token when rejected one is still the current one, otherwise the current token is returned
+	Refresh(ctx context.Context, rejected *Token) (*Token, error)
+}
+
+//ClientCredentials configures OAuth2 client credentials grant
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	//HTTPClient is used to call token endpoint, http.DefaultClient by default
+	HTTPClient *http.Client
+	//ExpiryLeeway is how long before expiry token is refreshed, 30 seconds by default
+	ExpiryLeeway time.Duration
+}
+
+//ClientCredentialsSource is TokenSource performing client credentials grant, it caches the token until it's about to expire
+//and makes sure only one refresh is in progress at a time
+type ClientCredentialsSource struct {
+	conf ClientCredentials
+	now  func() time.Time
+
+	mu         sync.Mutex
+	token      *Token
+	refreshing chan struct{}
+}
+
+//NewClientCredentialsSource behaves as a constructor
+func NewClientCredentialsSource(conf ClientCredentials) *ClientCredentialsSource {
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = http.DefaultClient
+	}
+	if conf.ExpiryLeeway <= 0 {
+		conf.ExpiryLeeway = defaultExpiryLeeway
+	}
+	return &ClientCredentialsSource{conf: conf, now: time.Now}
+}
+
+//Token as in TokenSource interface implementation
+func (s *ClientCredentialsSource) Token(ctx context.Context) (*Token, error) {
+	return s.get(ctx, nil)
+}
+
+//Refresh as in TokenSource interface implementation
+func (s *ClientCredentialsSource) Refresh(ctx context.Context, rejected *Token) (*Token, error) {
+	return s.get(ctx, rejected)
+}
+
+func (s *ClientCredentialsSource) get(ctx context.Context, rejected *Token) (*Token, error) {
+	for {
+		s.mu.Lock()
+		if s.token != nil && s.token != rejected && (s.token.Expiry.IsZero() || s.now().Add(s.conf.ExpiryLeeway).Before(s.token.Expiry)) {
+			token := s.token
+			s.mu.Unlock()
+			return token, nil
+		}
+		if s.refreshing != nil {
+			//another caller is refreshing already, wait for it and check its outcome
+			refreshing := s.refreshing
+			s.mu.Unlock()
+			select {
+			case <-refreshing:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		refreshing := make(chan struct{})
+		s.refreshing = refreshing
+		s.mu.Unlock()
+
+		token, err := s.fetch(ctx)
+
+		s.mu.Lock()
+		if err == nil {
+			s.token = token
+		}
+		s.refreshing = nil
+		close(refreshing)
+		s.mu.Unlock()
+		return token, err
+	}
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (s *ClientCredentialsSource) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": []string{"client_credentials"}}
+	if len(s.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.conf.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create new token RequestWithContext")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.conf.ClientID), url.QueryEscape(s.conf.ClientSecret))
+
+	issued := s.now()
+	resp, err := s.conf.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "token request failed")
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, errors.Wrap(err, "failed to decode token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("token endpoint responded with %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.AccessToken == "" {
+		return nil, errors.New("token response doesn't contain access_token")
+	}
+
+	token := &Token{AccessToken: body.AccessToken, TokenType: body.TokenType}
+	if body.ExpiresIn > 0 {
+		token.Expiry = issued.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+//authorize sets Authorization header from the token source and repeats the request once with refreshed token
+//when API responds with 401
+func authorize(ts TokenSource) RoundTripMiddleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			token, err := ts.Token(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get token")
+			}
+			if err := rewindable(req); err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", token.authorization())
+			resp, err := next(req)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			token, err = ts.Refresh(ctx, token)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to refresh token")
+			}
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					return nil, errors.Wrap(err, "failed to rewind request body")
+				}
+			}
+			req.Header.Set("Authorization", token.authorization())
+			return next(req)
+		}
+	}
+}
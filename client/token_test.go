@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer issues tokens token-1, token-2... valid for expiresIn seconds
+func tokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int64) {
+	var issued int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"client authentication failed"}`))
+			return
+		}
+		assert.Equal(t, "accounts:read accounts:write", r.FormValue("scope"))
+		//keep concurrent callers waiting long enough to pile up
+		time.Sleep(10 * time.Millisecond)
+		n := atomic.AddInt64(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &issued
+}
+
+func testClientCredentials(srv *httptest.Server) ClientCredentials {
+	return ClientCredentials{
+		TokenURL:     srv.URL + "/oauth2/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"accounts:read", "accounts:write"},
+		HTTPClient:   srv.Client(),
+	}
+}
+
+func TestClientCredentialsSource(t *testing.T) {
+	srv, issued := tokenServer(t, 3600)
+	ts := NewClientCredentialsSource(testClientCredentials(srv))
+	clock := time.Now()
+	ts.now = func() time.Time { return clock }
+
+	//single flight
+	var wg sync.WaitGroup
+	tokens := make([]*Token, 20)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := ts.Token(context.Background())
+			assert.NoError(t, err)
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), atomic.LoadInt64(issued))
+	for _, token := range tokens {
+		assert.Equal(t, "token-1", token.AccessToken)
+	}
+
+	//cached until near expiry
+	clock = clock.Add(3600*time.Second - defaultExpiryLeeway - time.Second)
+	token, err := ts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	clock = clock.Add(2 * time.Second)
+	token, err = ts.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+
+	//refresh of the token that was already replaced doesn't fetch another one
+	refreshed, err := ts.Refresh(context.Background(), tokens[0])
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", refreshed.AccessToken)
+	refreshed, err = ts.Refresh(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "token-3", refreshed.AccessToken)
+}
+
+func TestClientCredentialsSource_Error(t *testing.T) {
+	srv, _ := tokenServer(t, 3600)
+	conf := testClientCredentials(srv)
+	conf.ClientSecret = "wrong"
+
+	_, err := NewClientCredentialsSource(conf).Token(context.Background())
+	assert.EqualError(t, err, "token endpoint responded with 401: invalid_client client authentication failed")
+}
+
+func TestWithTokenSource(t *testing.T) {
+	srv, issued := tokenServer(t, 3600)
+	ts := NewClientCredentialsSource(testClientCredentials(srv))
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	accepted := "token-1"
+	var bodies []string
+	httpmock.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		if req.Header.Get("Authorization") != "Bearer "+accepted {
+			return httpmock.NewStringResponse(http.StatusUnauthorized, ""), nil
+		}
+		return httpmock.NewStringResponse(http.StatusCreated, ""), nil
+	})
+
+	c, err := NewDefaultClient(validTestBaseURL, WithTokenSource(ts))
+	require.NoError(t, err)
+
+	resp, err := c.Post(context.Background(), "/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	//token revoked by the API is refreshed and request repeated once
+	accepted = "token-2"
+	resp, err = c.Post(context.Background(), "/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{`{"data":{}}`, `{"data":{}}`, `{"data":{}}`}, bodies)
+
+	//second 401 is returned as it is
+	accepted = "none"
+	resp, err = c.Post(context.Background(), "/v1/organisation/accounts", strings.NewReader(`{"data":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int64(3), atomic.LoadInt64(issued))
+	assert.Equal(t, 5, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,71 @@
+package vcr
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const cassetteVersion = 1
+
+//Cassette holds interactions in order they were recorded
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+//Interaction is a single request and the response API returned for it
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+//Request as recorded, URL is absolute while matchers compare only its path and query by default
+//so that cassettes can be replayed against any base URL
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+//Response as recorded
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+//LoadCassette reads cassette from JSON file
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read cassette: %s", path)
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode cassette: %s", path)
+	}
+	if c.Version != cassetteVersion {
+		return nil, errors.Errorf("unsupported cassette version %d: %s", c.Version, path)
+	}
+	return c, nil
+}
+
+//Save writes cassette to JSON file creating missing directories
+func (c *Cassette) Save(path string) error {
+	if c.Interactions == nil {
+		c.Interactions = []Interaction{}
+	}
+	c.Version = cassetteVersion
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode cassette")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to save cassette: %s", path)
+	}
+	return errors.Wrapf(os.WriteFile(path, append(b, '\n'), 0644), "failed to save cassette: %s", path)
+}
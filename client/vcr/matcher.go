@@ -0,0 +1,78 @@
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+//Matcher reports whether recorded request matches the one being sent, body is already read from req
+type Matcher func(req *http.Request, body []byte, recorded Request) bool
+
+//DefaultMatcher matches method, path, query and JSON body
+var DefaultMatcher = MatchAll(MatchMethod, MatchPathAndQuery, MatchJSONBody())
+
+//MatchAll matches when all of the matchers do
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(req *http.Request, body []byte, recorded Request) bool {
+		for _, m := range matchers {
+			if !m(req, body, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+//MatchMethod matches request method
+func MatchMethod(req *http.Request, _ []byte, recorded Request) bool {
+	return req.Method == recorded.Method
+}
+
+//MatchPathAndQuery matches URL path and query params regardless of their order, scheme and host are ignored
+func MatchPathAndQuery(req *http.Request, _ []byte, recorded Request) bool {
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return req.URL.Path == u.Path && reflect.DeepEqual(req.URL.Query(), u.Query())
+}
+
+//MatchBody matches request body byte by byte
+func MatchBody(_ *http.Request, body []byte, recorded Request) bool {
+	return bytes.Equal(body, []byte(recorded.Body))
+}
+
+//MatchJSONBody matches semantically equal JSON bodies, fields given as dot separated paths e.g. data.id are ignored
+//so that values generated by tests don't prevent matching. Bodies which aren't valid JSON are compared byte by byte.
+func MatchJSONBody(ignore ...string) Matcher {
+	return func(req *http.Request, body []byte, recorded Request) bool {
+		if len(body) == 0 || len(recorded.Body) == 0 {
+			return len(body) == len(recorded.Body)
+		}
+		var actual, expected interface{}
+		if json.Unmarshal(body, &actual) != nil || json.Unmarshal([]byte(recorded.Body), &expected) != nil {
+			return MatchBody(req, body, recorded)
+		}
+		for _, path := range ignore {
+			remove(actual, strings.Split(path, "."))
+			remove(expected, strings.Split(path, "."))
+		}
+		return reflect.DeepEqual(actual, expected)
+	}
+}
+
+func remove(v interface{}, path []string) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		delete(obj, path[0])
+		return
+	}
+	remove(obj[path[0]], path[1:])
+}
@@ -0,0 +1,92 @@
+package vcr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		name     string
+		matcher  Matcher
+		method   string
+		url      string
+		body     string
+		recorded Request
+		expect   bool
+	}{
+		{
+			name:     "default matcher ignores host",
+			matcher:  DefaultMatcher,
+			method:   http.MethodGet,
+			url:      "https://api.example.com/v1/organisation/accounts?page[size]=1&page[number]=2",
+			recorded: Request{Method: http.MethodGet, URL: "http://localhost:8080/v1/organisation/accounts?page[number]=2&page[size]=1"},
+			expect:   true,
+		},
+		{
+			name:     "different method",
+			matcher:  DefaultMatcher,
+			method:   http.MethodDelete,
+			url:      "http://localhost:8080/v1/organisation/accounts",
+			recorded: Request{Method: http.MethodGet, URL: "http://localhost:8080/v1/organisation/accounts"},
+		},
+		{
+			name:     "different query",
+			matcher:  DefaultMatcher,
+			method:   http.MethodDelete,
+			url:      "http://localhost:8080/v1/organisation/accounts/1?version=1",
+			recorded: Request{Method: http.MethodDelete, URL: "http://localhost:8080/v1/organisation/accounts/1?version=0"},
+		},
+		{
+			name:     "equal JSON bodies with different formatting",
+			matcher:  MatchJSONBody(),
+			body:     `{"data": {"id": "1", "type": "accounts"}}`,
+			recorded: Request{Body: `{"data":{"type":"accounts","id":"1"}}` + "\n"},
+			expect:   true,
+		},
+		{
+			name:     "different JSON bodies",
+			matcher:  MatchJSONBody(),
+			body:     `{"data":{"id":"2","type":"accounts"}}`,
+			recorded: Request{Body: `{"data":{"id":"1","type":"accounts"}}`},
+		},
+		{
+			name:     "ignored JSON fields",
+			matcher:  MatchJSONBody("data.id", "data.organisation_id"),
+			body:     `{"data":{"id":"2","organisation_id":"3","type":"accounts"}}`,
+			recorded: Request{Body: `{"data":{"id":"1","type":"accounts"}}`},
+			expect:   true,
+		},
+		{
+			name:     "body isn't JSON",
+			matcher:  MatchJSONBody(),
+			body:     `name=fake`,
+			recorded: Request{Body: `name=fake`},
+			expect:   true,
+		},
+		{
+			name:     "missing body",
+			matcher:  MatchJSONBody(),
+			recorded: Request{Body: `{}`},
+		},
+		{
+			name:     "exact body",
+			matcher:  MatchBody,
+			body:     `{"data":{}}`,
+			recorded: Request{Body: `{"data": {}}`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method, url := test.method, test.url
+			if url == "" {
+				method, url = http.MethodPost, "http://localhost:8080/v1/organisation/accounts"
+			}
+			req, err := http.NewRequest(method, url, strings.NewReader(test.body))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expect, test.matcher(req, []byte(test.body), test.recorded))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+//Package vcr records interactions of DefaultClient with the API into cassette files and replays them in tests
+//so that they run offline and deterministically without hand written responders
+package vcr
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Gobonoid/form/client"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+//Mode of the Recorder
+type Mode int
+
+const (
+	//ModeReplay serves responses from the cassette and fails requests it has no interaction for
+	ModeReplay Mode = iota
+	//ModeRecord sends requests to the API and records them, cassette is overwritten on Save
+	ModeRecord
+)
+
+//Redacted replaces values of redacted headers
+const Redacted = "REDACTED"
+
+//ErrUnmatchedRequest is returned in replay mode for requests without any matching interaction left in the cassette
+var ErrUnmatchedRequest = errors.New("no matching interaction in cassette")
+
+//Recorder records or replays interactions, in replay mode each recorded interaction is served once in order
+//they were recorded so that repeated requests can get different responses e.g. before and after update
+type Recorder struct {
+	path     string
+	mode     Mode
+	matcher  Matcher
+	redacted []string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+//Option configures Recorder
+type Option func(r *Recorder)
+
+//WithMatcher replaces DefaultMatcher
+func WithMatcher(m Matcher) Option {
+	return func(r *Recorder) { r.matcher = m }
+}
+
+//WithRedactedHeaders adds headers whose values are replaced with Redacted before interaction is recorded,
+//Authorization, Proxy-Authorization, Cookie, Set-Cookie and Signature are always redacted
+func WithRedactedHeaders(headers ...string) Option {
+	return func(r *Recorder) { r.redacted = append(r.redacted, headers...) }
+}
+
+//New creates Recorder for the cassette at path, in replay mode the cassette has to exist
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		matcher:  DefaultMatcher,
+		redacted: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "Signature"},
+		cassette: &Cassette{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	switch mode {
+	case ModeReplay:
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	case ModeRecord:
+	default:
+		return nil, errors.Errorf("unknown mode %d", mode)
+	}
+	return r, nil
+}
+
+//Middleware to be passed to client.WithMiddleware, requests are recorded before token and signature are added
+//by DefaultClient so use Transport when those have to be recorded as well
+func (r *Recorder) Middleware() client.RoundTripMiddleware {
+	return func(next client.RoundTripFunc) client.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return r.roundTrip(req, next)
+		}
+	}
+}
+
+//Transport wraps next so that it can be set on http.Client passed to client.WithHTTPClient
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		return r.roundTrip(req, next.RoundTrip)
+	})
+}
+
+//Save writes recorded interactions to the cassette, it does nothing in replay mode
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+//RoundTrip as in http.RoundTripper interface implementation
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (r *Recorder) roundTrip(req *http.Request, next client.RoundTripFunc) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body, next)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !r.matcher(req, body, interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+		recorded := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.Wrapf(ErrUnmatchedRequest, "%s %s", req.Method, req.URL)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, next client.RoundTripFunc) (*http.Response, error) {
+	resp, err := next(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: r.redact(req.Header),
+			Body:   string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.redact(resp.Header),
+			Body:       string(respBody),
+		},
+	}
+	//Content-Length of the recorded body is set on replay
+	interaction.Response.Header.Del("Content-Length")
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) redact(h http.Header) http.Header {
+	redacted := h.Clone()
+	if redacted == nil {
+		redacted = http.Header{}
+	}
+	for _, name := range r.redacted {
+		if len(redacted.Values(name)) > 0 {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,146 @@
+package vcr_test
+
+import (
+	"context"
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/client"
+	"github.com/Gobonoid/form/client/vcr"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	accountID      = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+	missingID      = "5f8ae5c2-1f1e-4c4e-9a1c-8f0a1f8f2b55"
+	organisationID = "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c"
+)
+
+func createReq() form.CreateAccountReq {
+	country := "GB"
+	return form.CreateAccountReq{
+		ID:             accountID,
+		OrganisationID: organisationID,
+		Type:           "accounts",
+		Attributes: &form.AccountAttributes{
+			Country:       &country,
+			BankID:        "400300",
+			BankIDCode:    "GBDSC",
+			AccountNumber: "41426819",
+			Bic:           "NWBKGB22",
+			Name:          []string{"Samantha Holder"},
+		},
+	}
+}
+
+//interact runs the same sequence of requests in both modes
+func interact(t *testing.T, accounts *form.AccountAPIClient) *form.AccountData {
+	ctx := context.Background()
+	created, err := accounts.CreateAccountWithResult(ctx, createReq())
+	require.NoError(t, err)
+	_, err = accounts.FetchAccountByID(ctx, missingID)
+	assert.ErrorIs(t, err, form.ErrNotFound{})
+	require.NoError(t, accounts.DeleteAccountByID(ctx, accountID, 0))
+	_, err = accounts.FetchAccountByID(ctx, accountID)
+	assert.ErrorIs(t, err, form.ErrNotFound{})
+	return created
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassettes", "accounts.json")
+
+	srv := formtest.NewServer()
+	rec, err := vcr.New(cassette, vcr.ModeRecord)
+	require.NoError(t, err)
+	c, err := client.NewDefaultClient(srv.URL, client.WithHTTPClient(srv.Client()), client.WithMiddleware(rec.Middleware()))
+	require.NoError(t, err)
+	recorded := interact(t, form.NewAccountAPIClient(c))
+	require.NoError(t, rec.Save())
+	srv.Close()
+
+	//replay doesn't need the API at all and matches requests regardless of the host they're sent to
+	rec, err = vcr.New(cassette, vcr.ModeReplay)
+	require.NoError(t, err)
+	c, err = client.NewDefaultClient("api.example.com", client.WithMiddleware(rec.Middleware()))
+	require.NoError(t, err)
+	accounts := form.NewAccountAPIClient(c)
+	replayed := interact(t, accounts)
+	assert.Equal(t, recorded, replayed)
+
+	//every interaction is served only once
+	_, err = accounts.FetchAccountByID(context.Background(), missingID)
+	assert.ErrorIs(t, err, vcr.ErrUnmatchedRequest)
+	assert.Contains(t, err.Error(), "GET http://api.example.com/v1/organisation/accounts/"+missingID)
+}
+
+func TestRecorder_Replay_Unmatched(t *testing.T) {
+	rec, err := vcr.New("testdata/accounts.json", vcr.ModeReplay)
+	require.NoError(t, err)
+	c, err := client.NewDefaultClient("api.example.com", client.WithMiddleware(rec.Middleware()))
+	require.NoError(t, err)
+	accounts := form.NewAccountAPIClient(c)
+
+	//body differs from the recorded one
+	req := createReq()
+	req.Attributes.Name = []string{"Someone Else"}
+	_, err = accounts.CreateAccountWithResult(context.Background(), req)
+	assert.ErrorIs(t, err, vcr.ErrUnmatchedRequest)
+
+	created, err := accounts.CreateAccountWithResult(context.Background(), createReq())
+	require.NoError(t, err)
+	assert.Equal(t, accountID, created.ID)
+}
+
+func TestRecorder_RedactsHeaders(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "accounts.json")
+	srv := formtest.NewServer()
+	defer srv.Close()
+
+	rec, err := vcr.New(cassette, vcr.ModeRecord, vcr.WithRedactedHeaders("X-Api-Key"))
+	require.NoError(t, err)
+	c, err := client.NewDefaultClient(srv.URL,
+		client.WithHTTPClient(&http.Client{Transport: rec.Transport(srv.Client().Transport)}),
+		client.WithMiddleware(client.Headers(http.Header{"Authorization": {"Bearer token"}, "X-Api-Key": {"secret"}})),
+	)
+	require.NoError(t, err)
+	_, err = form.NewAccountAPIClient(c).FetchAccountByID(context.Background(), missingID)
+	assert.ErrorIs(t, err, form.ErrNotFound{})
+	require.NoError(t, rec.Save())
+
+	loaded, err := vcr.LoadCassette(cassette)
+	require.NoError(t, err)
+	require.Len(t, loaded.Interactions, 1)
+	header := loaded.Interactions[0].Request.Header
+	assert.Equal(t, vcr.Redacted, header.Get("Authorization"))
+	assert.Equal(t, vcr.Redacted, header.Get("X-Api-Key"))
+	assert.NotEmpty(t, loaded.Interactions[0].Response.Header.Get("X-Request-Id"))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		mode        vcr.Mode
+		expectError string
+	}{
+		{name: "replay", path: "testdata/accounts.json", mode: vcr.ModeReplay},
+		{name: "record doesn't require cassette", path: "testdata/missing.json", mode: vcr.ModeRecord},
+		{name: "replay requires cassette", path: "testdata/missing.json", mode: vcr.ModeReplay, expectError: "failed to read cassette: testdata/missing.json"},
+		{name: "unknown mode", path: "testdata/accounts.json", mode: vcr.Mode(5), expectError: "unknown mode 5"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := vcr.New(test.path, test.mode)
+			if test.expectError != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), test.expectError), err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
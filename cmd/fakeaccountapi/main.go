@@ -0,0 +1,147 @@
+//fakeaccountapi serves form accounts API backed by the in-process fake from formtest package so that integration
+//tests can run against a single process instead of the real API with its Postgres and Vault
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const resetPath = "/admin/reset"
+
+type config struct {
+	addr     string
+	data     string
+	seed     string
+	scenario string
+}
+
+func main() {
+	var conf config
+	flag.StringVar(&conf.addr, "addr", ":8080", "address to listen on")
+	flag.StringVar(&conf.data, "data", "", "JSON file accounts are persisted to across restarts, accounts are kept in memory only when empty")
+	flag.StringVar(&conf.seed, "seed", "", "JSON file with accounts loaded on start when there are no persisted ones and on reset")
+	flag.StringVar(&conf.scenario, "scenario", "", "YAML or JSON file with faults injected into API requests")
+	flag.Parse()
+
+	s, err := newServer(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := &http.Server{Addr: conf.addr, Handler: s}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("fake account API listening on %s", conf.addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+//server routes admin requests itself and all the others to the fake API through fault injector,
+//accounts are persisted after every request which may have modified them
+type server struct {
+	api    *formtest.API
+	faults *formtest.FaultInjector
+	store  *fileStore
+	seed   []form.AccountData
+}
+
+func newServer(conf config) (*server, error) {
+	api := formtest.NewAPI()
+	s := &server{api: api, faults: formtest.NewFaultInjector(api)}
+	if conf.scenario != "" {
+		sc, err := formtest.LoadScenario(conf.scenario)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.faults.SetScenario(sc); err != nil {
+			return nil, err
+		}
+	}
+	if conf.seed != "" {
+		seed, err := loadAccounts(conf.seed)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load seed")
+		}
+		s.seed = seed
+	}
+
+	accounts := s.seed
+	if conf.data != "" {
+		s.store = &fileStore{path: conf.data}
+		persisted, err := loadAccounts(conf.data)
+		switch {
+		case err == nil:
+			accounts = persisted
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, errors.Wrap(err, "failed to load persisted accounts")
+		}
+	}
+	api.Load(accounts)
+	return s, s.persist()
+}
+
+//ServeHTTP as in http.Handler interface implementation
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/admin/") {
+		s.admin(w, r)
+		return
+	}
+	s.faults.ServeHTTP(w, r)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if err := s.persist(); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+//admin handles POST /admin/reset which restores seed accounts and restarts fault scenario counters
+func (s *server) admin(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != resetPath {
+		writeError(w, http.StatusNotFound, "route not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.api.Load(s.seed)
+	_ = s.faults.SetScenario(s.faults.Scenario())
+	if err := s.persist(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) persist() error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.save(s.api)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error_message": message})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/client"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const seedAccountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+
+func start(t *testing.T, conf config) (*httptest.Server, *form.AccountAPIClient) {
+	s, err := newServer(conf)
+	require.NoError(t, err)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	c, err := client.NewDefaultClient(srv.URL, client.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	return srv, form.NewAccountAPIClient(c)
+}
+
+func createReq() form.CreateAccountReq {
+	country := "GB"
+	return form.CreateAccountReq{
+		ID:             uuid.New().String(),
+		OrganisationID: uuid.New().String(),
+		Type:           "accounts",
+		Attributes: &form.AccountAttributes{
+			Country:       &country,
+			BankID:        "400300",
+			BankIDCode:    "GBDSC",
+			AccountNumber: "41426819",
+			Bic:           "NWBKGB22",
+			Name:          []string{"Samantha Holder"},
+		},
+	}
+}
+
+func reset(t *testing.T, srv *httptest.Server) int {
+	resp, err := srv.Client().Post(srv.URL+resetPath, "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServer_PersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	conf := config{data: filepath.Join(t.TempDir(), "accounts.json"), seed: "testdata/seed.json"}
+
+	_, accounts := start(t, conf)
+	req := createReq()
+	require.NoError(t, accounts.CreateAccount(ctx, req))
+	require.NoError(t, accounts.DeleteAccountByID(ctx, seedAccountID, 0))
+
+	//persisted accounts take precedence over the seed
+	_, restarted := start(t, conf)
+	created, err := restarted.FetchAccountByID(ctx, req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, req.ID, created.ID)
+	_, err = restarted.FetchAccountByID(ctx, seedAccountID)
+	assert.ErrorAs(t, err, &form.ErrNotFound{})
+}
+
+func TestServer_Reset(t *testing.T) {
+	ctx := context.Background()
+	conf := config{data: filepath.Join(t.TempDir(), "accounts.json"), seed: "testdata/seed.json"}
+
+	srv, accounts := start(t, conf)
+	req := createReq()
+	require.NoError(t, accounts.CreateAccount(ctx, req))
+	require.NoError(t, accounts.DeleteAccountByID(ctx, seedAccountID, 0))
+
+	assert.Equal(t, http.StatusNoContent, reset(t, srv))
+	_, err := accounts.FetchAccountByID(ctx, req.ID)
+	assert.ErrorAs(t, err, &form.ErrNotFound{})
+	seeded, err := accounts.FetchAccountByID(ctx, seedAccountID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Samantha Holder"}, seeded.Attributes.Name)
+
+	//reset state is persisted as well
+	persisted, err := loadAccounts(conf.data)
+	require.NoError(t, err)
+	assert.Len(t, persisted, 2)
+
+	resp, err := srv.Client().Get(srv.URL + resetPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServer_Scenario(t *testing.T) {
+	srv, _ := start(t, config{scenario: "testdata/scenario.yaml"})
+	list := func() int {
+		resp, err := srv.Client().Get(srv.URL + "/v1/organisation/accounts")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, list())
+	assert.Equal(t, http.StatusOK, list())
+
+	//reset restarts fault counters so that the scenario can be replayed
+	assert.Equal(t, http.StatusNoContent, reset(t, srv))
+	assert.Equal(t, http.StatusServiceUnavailable, list())
+}
+
+func TestNewServer(t *testing.T) {
+	dir := t.TempDir()
+	corrupted := filepath.Join(dir, "corrupted.json")
+	require.NoError(t, os.WriteFile(corrupted, []byte("{"), 0600))
+
+	tests := []struct {
+		name        string
+		conf        config
+		expectError string
+	}{
+		{
+			name: "in memory only",
+			conf: config{},
+		},
+		{
+			name: "missing data file is created",
+			conf: config{data: filepath.Join(dir, "accounts.json")},
+		},
+		{
+			name:        "missing seed file",
+			conf:        config{seed: filepath.Join(dir, "missing.json")},
+			expectError: "failed to load seed: failed to read accounts: " + filepath.Join(dir, "missing.json"),
+		},
+		{
+			name:        "corrupted data file",
+			conf:        config{data: corrupted},
+			expectError: "failed to load persisted accounts: failed to decode accounts: " + corrupted,
+		},
+		{
+			name:        "missing scenario file",
+			conf:        config{scenario: filepath.Join(dir, "missing.yaml")},
+			expectError: "failed to read scenario: " + filepath.Join(dir, "missing.yaml"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newServer(test.conf)
+			if test.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectError)
+				return
+			}
+			require.NoError(t, err)
+			if test.conf.data != "" {
+				assert.FileExists(t, test.conf.data)
+			}
+		})
+	}
+}
+
+func TestServer_Contract(t *testing.T) {
+	s, err := newServer(config{data: filepath.Join(t.TempDir(), "accounts.json"), seed: "testdata/seed.json"})
+	require.NoError(t, err)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	formtest.RunAccountContract(t, func(t *testing.T) form.HTTPClient {
+		c, err := client.NewDefaultClient(srv.URL, client.WithHTTPClient(srv.Client()))
+		require.NoError(t, err)
+		return c
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//accountsFile is the format of both data and seed files, it's the same as list accounts response
+//so that a seed can be dumped from a running API with curl
+type accountsFile struct {
+	Data []form.AccountData `json:"data"`
+}
+
+func loadAccounts(path string) ([]form.AccountData, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read accounts: %s", path)
+	}
+	var f accountsFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode accounts: %s", path)
+	}
+	return f.Data, nil
+}
+
+//fileStore persists accounts of the API to JSON file, the file is replaced atomically so that it's never left half written
+type fileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+//save writes current accounts of the API, snapshot is taken under the lock so that older state never overwrites newer one
+func (s *fileStore) save(api *formtest.API) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := json.MarshalIndent(accountsFile{Data: api.Accounts()}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode accounts")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to save accounts: %s", s.path)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to save accounts: %s", s.path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to save accounts: %s", s.path)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), s.path), "failed to save accounts: %s", s.path)
+}
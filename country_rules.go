@@ -0,0 +1,157 @@
+package form
+
+import (
+	"fmt"
+	"github.com/Gobonoid/form/bic"
+	"regexp"
+)
+
+func required(rule FieldRule) FieldRule {
+	rule.Required = true
+	return rule
+}
+
+func digits(min, max int) FieldRule {
+	if min == max {
+		return FieldRule{Pattern: regexp.MustCompile(fmt.Sprintf(`^[0-9]{%d}$`, min)), Format: fmt.Sprintf("%d digits", min)}
+	}
+	return FieldRule{Pattern: regexp.MustCompile(fmt.Sprintf(`^[0-9]{%d,%d}$`, min, max)), Format: fmt.Sprintf("%d to %d digits", min, max)}
+}
+
+func characters(n int) FieldRule {
+	return FieldRule{Pattern: regexp.MustCompile(fmt.Sprintf(`^[0-9A-Z]{%d}$`, n)), Format: fmt.Sprintf("%d alphanumeric characters", n)}
+}
+
+func exactly(value string) FieldRule {
+	return FieldRule{Pattern: regexp.MustCompile("^" + regexp.QuoteMeta(value) + "$"), Format: value}
+}
+
+func bicFormat() FieldRule {
+	return FieldRule{Pattern: bic.Pattern, Format: "8 or 11 character BIC"}
+}
+
+func ibanFormat() FieldRule {
+	return FieldRule{Pattern: ibanRegexp, Format: "IBAN in electronic format"}
+}
+
+func notSupported() FieldRule {
+	return FieldRule{NotSupported: true}
+}
+
+//defaultCountryRules as documented in form accounts API "Account data by country"
+func defaultCountryRules() map[string]CountryRules {
+	return map[string]CountryRules{
+		"AU": {
+			BankID:        digits(6, 6),
+			BankIDCode:    required(exactly("AUBSB")),
+			Bic:           required(bicFormat()),
+			AccountNumber: FieldRule{Pattern: regexp.MustCompile(`^[1-9][0-9]{5,9}$`), Format: "6 to 10 digits not starting with 0"},
+			Iban:          notSupported(),
+		},
+		"BE": {
+			BankID:        required(digits(3, 3)),
+			BankIDCode:    required(exactly("BE")),
+			Bic:           bicFormat(),
+			AccountNumber: digits(7, 7),
+			Iban:          ibanFormat(),
+		},
+		"CA": {
+			BankID:        FieldRule{Pattern: regexp.MustCompile(`^0[0-9]{8}$`), Format: "9 digits starting with 0"},
+			BankIDCode:    exactly("CACPA"),
+			Bic:           required(bicFormat()),
+			AccountNumber: digits(7, 12),
+			Iban:          notSupported(),
+		},
+		"CH": {
+			BankID:        required(digits(5, 5)),
+			BankIDCode:    required(exactly("CHBCC")),
+			Bic:           bicFormat(),
+			AccountNumber: characters(12),
+			Iban:          ibanFormat(),
+		},
+		"DE": {
+			BankID:        required(digits(8, 8)),
+			BankIDCode:    required(exactly("DEBLZ")),
+			Bic:           bicFormat(),
+			AccountNumber: digits(7, 10),
+			Iban:          ibanFormat(),
+		},
+		"ES": {
+			BankID:        required(digits(8, 8)),
+			BankIDCode:    required(exactly("ESNCC")),
+			Bic:           bicFormat(),
+			AccountNumber: digits(10, 10),
+			Iban:          ibanFormat(),
+		},
+		"FR": {
+			BankID:        required(digits(10, 10)),
+			BankIDCode:    required(exactly("FR")),
+			Bic:           bicFormat(),
+			AccountNumber: characters(11),
+			Iban:          ibanFormat(),
+		},
+		"GB": {
+			BankID:        required(digits(6, 6)),
+			BankIDCode:    required(exactly("GBDSC")),
+			Bic:           required(bicFormat()),
+			AccountNumber: digits(8, 8),
+			Iban:          ibanFormat(),
+		},
+		"GR": {
+			BankID:        required(digits(7, 7)),
+			BankIDCode:    required(exactly("GRBIC")),
+			Bic:           bicFormat(),
+			AccountNumber: digits(16, 16),
+			Iban:          ibanFormat(),
+		},
+		"HK": {
+			BankID:        digits(3, 3),
+			BankIDCode:    required(exactly("HKNCC")),
+			Bic:           required(bicFormat()),
+			AccountNumber: digits(9, 12),
+			Iban:          notSupported(),
+		},
+		"IT": {
+			BankID:        required(digits(10, 11)),
+			BankIDCode:    required(exactly("ITNCC")),
+			Bic:           bicFormat(),
+			AccountNumber: characters(12),
+			Iban:          ibanFormat(),
+		},
+		"LU": {
+			BankID:        required(digits(3, 3)),
+			BankIDCode:    required(exactly("LULUX")),
+			Bic:           bicFormat(),
+			AccountNumber: characters(13),
+			Iban:          ibanFormat(),
+		},
+		"NL": {
+			BankID:        notSupported(),
+			BankIDCode:    notSupported(),
+			Bic:           required(bicFormat()),
+			AccountNumber: digits(10, 10),
+			Iban:          ibanFormat(),
+		},
+		"PL": {
+			BankID:        required(digits(8, 8)),
+			BankIDCode:    required(exactly("PLKNR")),
+			Bic:           bicFormat(),
+			AccountNumber: digits(16, 16),
+			Iban:          ibanFormat(),
+		},
+		"PT": {
+			BankID:        required(digits(8, 8)),
+			BankIDCode:    required(exactly("PTNCC")),
+			Bic:           bicFormat(),
+			AccountNumber: digits(11, 11),
+			Iban:          ibanFormat(),
+		},
+		"US": {
+			BankID:        required(digits(9, 9)),
+			BankIDCode:    required(exactly("USABA")),
+			Bic:           required(bicFormat()),
+			AccountNumber: digits(6, 17),
+			Iban:          notSupported(),
+		},
+	}
+}
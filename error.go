@@ -1,12 +1,18 @@
 package form
 
 import (
+	"context"
 	"fmt"
+	"github.com/Gobonoid/form/internal/neterr"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
 )
 
 //ErrUnexpectedStatusCode is returned when any request to form API returns response status code that can't be translated into more meaningful error
 type ErrUnexpectedStatusCode struct {
 	StatusCode int
+	APIError   *APIError
 }
 
 //Error as in error interface implementation
@@ -14,14 +20,43 @@ func (err ErrUnexpectedStatusCode) Error() string {
 	return fmt.Sprintf("unexepcterd error code %d", err.StatusCode)
 }
 
+//Is allows errors.Is(err, ErrUnexpectedStatusCode{}) to match any ErrUnexpectedStatusCode regardless of its fields
+func (err ErrUnexpectedStatusCode) Is(target error) bool {
+	_, ok := target.(ErrUnexpectedStatusCode)
+	return ok
+}
+
+//Temporary reports whether status code signals condition API is expected to recover from
+func (err ErrUnexpectedStatusCode) Temporary() bool {
+	return isTemporaryStatusCode(err.StatusCode)
+}
+
+//Unwrap allows errors.As to reach APIError
+func (err ErrUnexpectedStatusCode) Unwrap() error {
+	return unwrapAPIError(err.APIError)
+}
+
 //ErrNotFound is returned when resource doesn't exists
-type ErrNotFound struct{}
+type ErrNotFound struct {
+	APIError *APIError
+}
 
 //Error as in error interface implementation
 func (err ErrNotFound) Error() string {
 	return "not found"
 }
 
+//Is allows errors.Is(err, ErrNotFound{}) to match any ErrNotFound regardless of its fields
+func (err ErrNotFound) Is(target error) bool {
+	_, ok := target.(ErrNotFound)
+	return ok
+}
+
+//Unwrap allows errors.As to reach APIError
+func (err ErrNotFound) Unwrap() error {
+	return unwrapAPIError(err.APIError)
+}
+
 //ErrValidationError is returned when parameters passed to the client are known to be wrong
 type ErrValidationError struct {
 	Reason string
@@ -32,9 +67,74 @@ func (err ErrValidationError) Error() string {
 	return fmt.Sprintf("request body isn't valid: %s", err.Reason)
 }
 
-//ErrConflict when requested resource/action can't be completed due to logic conflict
+//Is allows errors.Is(err, ErrValidationError{}) to match any ErrValidationError regardless of its reason
+func (err ErrValidationError) Is(target error) bool {
+	_, ok := target.(ErrValidationError)
+	return ok
+}
+
+//Codes of rules FieldViolation can refer to
+const (
+	ViolationRequired        = "required"
+	ViolationNotSupported    = "not_supported"
+	ViolationInvalidFormat   = "invalid_format"
+	ViolationInvalidChecksum = "invalid_checksum"
+	ViolationMismatch        = "mismatch"
+)
+
+//FieldViolation describes why a single field of the request isn't valid,
+//Field is a path to the field in request body e.g. attributes.bank_id
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+//ValidationErrors is returned when parameters passed to the client are known to be wrong and aggregates all found violations
+type ValidationErrors []FieldViolation
+
+//Error as in error interface implementation
+func (errs ValidationErrors) Error() string {
+	return ErrValidationError{Reason: errs.reason()}.Error()
+}
+
+//Is allows errors.Is(err, ErrValidationError{}) to match ValidationErrors
+func (errs ValidationErrors) Is(target error) bool {
+	return ErrValidationError{}.Is(target)
+}
+
+//As allows ValidationErrors to be used wherever ErrValidationError is expected by errors.As
+func (errs ValidationErrors) As(target interface{}) bool {
+	if t, ok := target.(*ErrValidationError); ok {
+		*t = ErrValidationError{Reason: errs.reason()}
+		return true
+	}
+	return false
+}
+
+func (errs ValidationErrors) has(field string) bool {
+	for _, v := range errs {
+		if v.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (errs ValidationErrors) reason() string {
+	messages := make([]string, 0, len(errs))
+	for _, v := range errs {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+//ErrConflict when requested resource/action can't be completed due to logic conflict,
+//CurrentVersion is set when API reported version of the resource it holds
 type ErrConflict struct {
-	Reason string
+	Reason         string
+	CurrentVersion *int64
+	APIError       *APIError
 }
 
 //Error as in error interface implementation
@@ -42,12 +142,87 @@ func (err ErrConflict) Error() string {
 	return err.Reason
 }
 
+//Is allows errors.Is(err, ErrConflict{}) to match any ErrConflict regardless of its fields
+func (err ErrConflict) Is(target error) bool {
+	_, ok := target.(ErrConflict)
+	return ok
+}
+
+//Unwrap allows errors.As to reach APIError
+func (err ErrConflict) Unwrap() error {
+	return unwrapAPIError(err.APIError)
+}
+
+//ErrConflictingAccount is returned by CreateOrGetAccount when account with the same ID exists but differs from requested one
+type ErrConflictingAccount struct {
+	Existing  *AccountData
+	Requested CreateAccountReq
+}
+
+//Error as in error interface implementation
+func (err ErrConflictingAccount) Error() string {
+	return fmt.Sprintf("account %s already exists with different data", err.Requested.ID)
+}
+
+//Is allows errors.Is to match ErrConflictingAccount{} as well as ErrConflict{} as conflicting account is a conflict too
+func (err ErrConflictingAccount) Is(target error) bool {
+	switch target.(type) {
+	case ErrConflictingAccount, ErrConflict:
+		return true
+	}
+	return false
+}
+
 //ErrBadRequest is returned when form API returns BadRequest status code
 type ErrBadRequest struct {
-	Reason string
+	Reason   string
+	APIError *APIError
 }
 
 //Error as in error interface implementation
 func (err ErrBadRequest) Error() string {
 	return fmt.Sprintf("bad request: %s", err.Reason)
 }
+
+//Is allows errors.Is(err, ErrBadRequest{}) to match any ErrBadRequest regardless of its fields
+func (err ErrBadRequest) Is(target error) bool {
+	_, ok := target.(ErrBadRequest)
+	return ok
+}
+
+//Unwrap allows errors.As to reach APIError
+func (err ErrBadRequest) Unwrap() error {
+	return unwrapAPIError(err.APIError)
+}
+
+//unwrapAPIError avoids returning nil *APIError as non nil error
+func unwrapAPIError(apiErr *APIError) error {
+	if apiErr == nil {
+		return nil
+	}
+	return apiErr
+}
+
+//Retryable reports whether request which failed with err may succeed when repeated. 5xx and 429 responses, timeouts
+//and refused or reset connections are retryable, other 4xx responses, validation errors, TLS failures and cancelled
+//or expired contexts aren't.
+func Retryable(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	var unexpected ErrUnexpectedStatusCode
+	if errors.As(err, &unexpected) {
+		return unexpected.Temporary()
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	//request didn't get any response, only timeouts and refused or reset connections are worth repeating
+	return neterr.Temporary(err)
+}
+
+func isTemporaryStatusCode(statusCode int) bool {
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
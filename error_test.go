@@ -0,0 +1,123 @@
+package form_test
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"github.com/Gobonoid/form"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestErrorsIs(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		matches  []error
+		mismatch error
+	}{
+		{
+			name:     "not found",
+			err:      form.ErrNotFound{APIError: &form.APIError{StatusCode: http.StatusNotFound}},
+			matches:  []error{form.ErrNotFound{}},
+			mismatch: form.ErrConflict{},
+		},
+		{
+			name:     "validation error",
+			err:      form.ErrValidationError{Reason: "accountID isn't uuid"},
+			matches:  []error{form.ErrValidationError{}},
+			mismatch: form.ErrBadRequest{},
+		},
+		{
+			name:     "validation errors",
+			err:      form.ValidationErrors{{Field: "attributes", Code: form.ViolationRequired}},
+			matches:  []error{form.ErrValidationError{}},
+			mismatch: form.ErrBadRequest{},
+		},
+		{
+			name:     "conflict",
+			err:      form.ErrConflict{Reason: "account already exists"},
+			matches:  []error{form.ErrConflict{}},
+			mismatch: form.ErrConflictingAccount{},
+		},
+		{
+			name:     "conflicting account is a conflict",
+			err:      form.ErrConflictingAccount{},
+			matches:  []error{form.ErrConflictingAccount{}, form.ErrConflict{}},
+			mismatch: form.ErrNotFound{},
+		},
+		{
+			name:     "bad request",
+			err:      form.ErrBadRequest{Reason: "name is required"},
+			matches:  []error{form.ErrBadRequest{}},
+			mismatch: form.ErrValidationError{},
+		},
+		{
+			name:     "unexpected status code",
+			err:      form.ErrUnexpectedStatusCode{StatusCode: http.StatusTeapot},
+			matches:  []error{form.ErrUnexpectedStatusCode{}},
+			mismatch: form.ErrNotFound{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := errors.Wrap(fmt.Errorf("outer: %w", tt.err), "wrapped")
+			for _, target := range tt.matches {
+				assert.True(t, errors.Is(wrapped, target), "%T", target)
+			}
+			assert.False(t, errors.Is(wrapped, tt.mismatch))
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	urlErr := func(err error) error {
+		return errors.Wrap(errors.Wrap(&url.Error{Op: "Get", URL: "https://accountapi", Err: err}, "request failed"), "GET request failed")
+	}
+	opErr := func(op string, err error) error {
+		return &net.OpError{Op: op, Net: "tcp", Err: &os.SyscallError{Syscall: op, Err: err}}
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{name: "no error"},
+		{name: "not found", err: form.ErrNotFound{APIError: &form.APIError{StatusCode: http.StatusNotFound}}},
+		{name: "validation", err: form.ValidationErrors{}},
+		{name: "bad request", err: form.ErrBadRequest{APIError: &form.APIError{StatusCode: http.StatusBadRequest}}},
+		{name: "service unavailable", err: form.ErrUnexpectedStatusCode{StatusCode: http.StatusServiceUnavailable}, expect: true},
+		{name: "too many requests", err: form.ErrUnexpectedStatusCode{StatusCode: http.StatusTooManyRequests}, expect: true},
+		{name: "wrapped api error", err: errors.Wrap(&form.APIError{StatusCode: http.StatusBadGateway}, "failed"), expect: true},
+		{name: "connection refused", err: urlErr(opErr("connect", syscall.ECONNREFUSED)), expect: true},
+		{name: "connection reset", err: urlErr(opErr("read", syscall.ECONNRESET)), expect: true},
+		{name: "timeout", err: urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutErr{}}), expect: true},
+		{name: "unknown certificate authority", err: urlErr(x509.UnknownAuthorityError{})},
+		{name: "certificate hostname mismatch", err: urlErr(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "accountapi"})},
+		{name: "unsupported protocol scheme", err: urlErr(errors.New("unsupported protocol scheme \"ftp\""))},
+		{name: "cancelled context wrapped by transport", err: urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: context.Canceled})},
+		{name: "cancelled context", err: errors.Wrap(&url.Error{Op: "Get", URL: "http://accountapi", Err: context.Canceled}, "request failed")},
+		{name: "expired context", err: errors.Wrap(&url.Error{Op: "Get", URL: "http://accountapi", Err: context.DeadlineExceeded}, "request failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, form.Retryable(tt.err))
+		})
+	}
+}
+
+//timeoutErr is net.Error of timed out connection
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
@@ -0,0 +1,378 @@
+//Package formtest provides in-memory fake of form accounts API, fault injection for it and RunAccountContract
+//conformance suite. Unlike other packages of the module it imports testing and testify so that the suite can be run
+//from tests of any package, it's meant to be imported by _test.go files only.
+package formtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Gobonoid/form"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	accountsPath    = "/v1/organisation/accounts"
+	healthPath      = "/v1/health"
+	requestIDHeader = "X-Request-Id"
+	defaultPageSize = 100
+)
+
+//API is an in-memory http.Handler implementing form accounts API endpoints with the same status codes,
+//error messages and JSON:API envelopes as the real one
+type API struct {
+	mu       sync.RWMutex
+	accounts []*form.AccountData
+	byID     map[string]*form.AccountData
+	now      func() time.Time
+}
+
+//NewAPI creates API without any accounts
+func NewAPI() *API {
+	return &API{
+		byID: map[string]*form.AccountData{},
+		now:  time.Now,
+	}
+}
+
+//Accounts returns copy of all accounts in order they were created
+func (a *API) Accounts() []form.AccountData {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	accounts := make([]form.AccountData, 0, len(a.accounts))
+	for _, account := range a.accounts {
+		accounts = append(accounts, *copyAccount(account))
+	}
+	return accounts
+}
+
+//Load replaces all accounts with given ones, they're stored in given order
+func (a *API) Load(accounts []form.AccountData) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.accounts = nil
+	a.byID = map[string]*form.AccountData{}
+	for i := range accounts {
+		account := copyAccount(&accounts[i])
+		a.accounts = append(a.accounts, account)
+		a.byID[account.ID] = account
+	}
+}
+
+//Reset removes all accounts
+func (a *API) Reset() {
+	a.Load(nil)
+}
+
+//ServeHTTP as in http.Handler interface implementation
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(requestIDHeader, uuid.New().String())
+
+	path := strings.TrimRight(r.URL.Path, "/")
+	switch {
+	case path == healthPath && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]string{"status": "up"})
+	case path == accountsPath:
+		switch r.Method {
+		case http.MethodGet:
+			a.list(w, r)
+		case http.MethodPost:
+			a.create(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case strings.HasPrefix(path, accountsPath+"/") && !strings.Contains(path[len(accountsPath)+1:], "/"):
+		id := path[len(accountsPath)+1:]
+		if _, err := uuid.Parse(id); err != nil {
+			writeError(w, http.StatusBadRequest, "id is not a valid uuid")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			a.fetch(w, id)
+		case http.MethodDelete:
+			a.delete(w, r, id)
+		case http.MethodPatch:
+			a.patch(w, r, id)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "route not found")
+	}
+}
+
+type envelope struct {
+	Data  interface{} `json:"data"`
+	Links *form.Links `json:"links,omitempty"`
+}
+
+func (a *API) create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Data *form.CreateAccountReq `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Data == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if violations := validateCreate(body.Data); len(violations) > 0 {
+		writeError(w, http.StatusBadRequest, "validation failure list:\n"+strings.Join(violations, "\n"))
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.byID[body.Data.ID]; ok {
+		writeError(w, http.StatusConflict, "Account cannot be created as it violates a duplicate constraint")
+		return
+	}
+	version := int64(0)
+	now := a.now().UTC()
+	account := copyAccount(&form.AccountData{
+		Attributes:     body.Data.Attributes,
+		ID:             body.Data.ID,
+		OrganisationID: body.Data.OrganisationID,
+		Type:           body.Data.Type,
+		Version:        &version,
+		CreatedOn:      now,
+		ModifiedOn:     now,
+	})
+	a.accounts = append(a.accounts, account)
+	a.byID[account.ID] = account
+	writeJSON(w, http.StatusCreated, envelope{Data: account, Links: &form.Links{Self: accountsPath + "/" + account.ID}})
+}
+
+func validateCreate(req *form.CreateAccountReq) []string {
+	var violations []string
+	if _, err := uuid.Parse(req.ID); err != nil {
+		violations = append(violations, "id in body must be of type uuid: \""+req.ID+"\"")
+	}
+	if _, err := uuid.Parse(req.OrganisationID); err != nil {
+		violations = append(violations, "organisation_id in body must be of type uuid: \""+req.OrganisationID+"\"")
+	}
+	if req.Type != "accounts" {
+		violations = append(violations, "type in body should be one of [accounts]")
+	}
+	if req.Attributes == nil {
+		return append(violations, "attributes in body is required")
+	}
+	if len(req.Attributes.Name) == 0 {
+		violations = append(violations, "name in body is required")
+	}
+	if req.Attributes.Country == nil || *req.Attributes.Country == "" {
+		violations = append(violations, "country in body is required")
+	}
+	return violations
+}
+
+func (a *API) fetch(w http.ResponseWriter, id string) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	account, ok := a.byID[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: account, Links: &form.Links{Self: accountsPath + "/" + id}})
+}
+
+func (a *API) delete(w http.ResponseWriter, r *http.Request, id string) {
+	version, err := strconv.ParseInt(r.URL.Query().Get("version"), 10, 64)
+	if err != nil || version < 0 {
+		writeError(w, http.StatusBadRequest, "invalid version number")
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	account, ok := a.byID[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if *account.Version != version {
+		writeError(w, http.StatusConflict, "invalid version")
+		return
+	}
+	delete(a.byID, id)
+	for i := range a.accounts {
+		if a.accounts[i].ID == id {
+			a.accounts = append(a.accounts[:i], a.accounts[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) patch(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Data *struct {
+			Attributes json.RawMessage `json:"attributes"`
+			ID         string          `json:"id"`
+			Type       string          `json:"type"`
+			Version    *int64          `json:"version"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Data == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Data.ID != id || body.Data.Type != "accounts" || body.Data.Version == nil {
+		writeError(w, http.StatusBadRequest, "id, type and version in body are required and id has to match the path")
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	account, ok := a.byID[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+		return
+	}
+	if *account.Version != *body.Data.Version {
+		writeJSON(w, http.StatusConflict, struct {
+			ErrorMessage string            `json:"error_message"`
+			Data         *form.AccountData `json:"data"`
+		}{ErrorMessage: "invalid version", Data: account})
+		return
+	}
+
+	patched := copyAccount(account)
+	if patched.Attributes == nil {
+		patched.Attributes = &form.AccountAttributes{}
+	}
+	//attributes present in the patch overwrite existing ones, the rest is left as it was
+	if len(body.Data.Attributes) > 0 {
+		if err := json.Unmarshal(body.Data.Attributes, patched.Attributes); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid attributes")
+			return
+		}
+	}
+	version := *account.Version + 1
+	patched.Version = &version
+	patched.ModifiedOn = a.now().UTC()
+	*account = *patched
+	writeJSON(w, http.StatusOK, envelope{Data: account, Links: &form.Links{Self: accountsPath + "/" + id}})
+}
+
+func (a *API) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	number, size := 0, defaultPageSize
+	var err error
+	if v := q.Get("page[number]"); v != "" {
+		if number, err = strconv.Atoi(v); err != nil || number < 0 {
+			writeError(w, http.StatusBadRequest, "invalid page number")
+			return
+		}
+	}
+	if v := q.Get("page[size]"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 || size > defaultPageSize {
+			writeError(w, http.StatusBadRequest, "invalid page size")
+			return
+		}
+	}
+
+	a.mu.RLock()
+	matching := make([]*form.AccountData, 0, len(a.accounts))
+	for _, account := range a.accounts {
+		if matches(account, q) {
+			matching = append(matching, account)
+		}
+	}
+	data := []*form.AccountData{}
+	if start := number * size; start < len(matching) {
+		end := start + size
+		if end > len(matching) {
+			end = len(matching)
+		}
+		//accounts are patched in place so the page is copied before the lock is released
+		for _, account := range matching[start:end] {
+			data = append(data, copyAccount(account))
+		}
+	}
+	a.mu.RUnlock()
+
+	last := 0
+	if len(matching) > 0 {
+		last = (len(matching) - 1) / size
+	}
+
+	link := func(page int) string {
+		lq := url.Values{}
+		for k, v := range q {
+			if strings.HasPrefix(k, "filter[") {
+				lq[k] = v
+			}
+		}
+		lq.Set("page[number]", strconv.Itoa(page))
+		lq.Set("page[size]", strconv.Itoa(size))
+		return accountsPath + "?" + lq.Encode()
+	}
+	links := &form.Links{First: link(0), Last: link(last), Self: link(number)}
+	if number < last {
+		links.Next = link(number + 1)
+	}
+	if number > 0 {
+		links.Prev = link(number - 1)
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: data, Links: links})
+}
+
+//matches reports whether account satisfies all filter[attribute] query params
+func matches(account *form.AccountData, q url.Values) bool {
+	attrs := account.Attributes
+	if attrs == nil {
+		attrs = &form.AccountAttributes{}
+	}
+	country := ""
+	if attrs.Country != nil {
+		country = *attrs.Country
+	}
+	values := map[string]string{
+		"bank_id":         attrs.BankID,
+		"bank_id_code":    attrs.BankIDCode,
+		"account_number":  attrs.AccountNumber,
+		"iban":            attrs.Iban,
+		"country":         country,
+		"customer_id":     attrs.CustomerID,
+		"organisation_id": account.OrganisationID,
+	}
+	for k, v := range q {
+		if !strings.HasPrefix(k, "filter[") || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		actual, ok := values[k[len("filter["):len(k)-1]]
+		if !ok || len(v) == 0 || actual != v[0] {
+			return false
+		}
+	}
+	return true
+}
+
+//copyAccount deep copies account so that stored accounts are never shared with callers
+func copyAccount(account *form.AccountData) *form.AccountData {
+	b, err := json.Marshal(account)
+	if err != nil {
+		panic(err)
+	}
+	c := &form.AccountData{}
+	if err := json.Unmarshal(b, c); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error_message": message})
+}
@@ -0,0 +1,267 @@
+package formtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Gobonoid/form"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func createBody(id string, name string, country string) string {
+	return fmt.Sprintf(`{"data":{"id":"%s","organisation_id":"%s","type":"accounts","attributes":{"name":["%s"],"country":"%s","bank_id":"400300","account_number":"41426819"}}}`,
+		id, uuid.New().String(), name, country)
+}
+
+func do(t *testing.T, srv *Server, method, path, body string) (int, string) {
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.NotEmpty(t, resp.Header.Get(requestIDHeader))
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(b)
+}
+
+func TestAPI(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	clock := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	srv.API.now = func() time.Time { return clock }
+
+	id := uuid.New().String()
+	missingID := uuid.New().String()
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectStatus int
+		expectBody   string
+	}{
+		{
+			name:         "health",
+			method:       http.MethodGet,
+			path:         "/v1/health",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"status":"up"}`,
+		},
+		{
+			name:         "create",
+			method:       http.MethodPost,
+			path:         accountsPath,
+			body:         createBody(id, "fake account", "GB"),
+			expectStatus: http.StatusCreated,
+			expectBody:   `"version":0,"created_on":"2021-06-01T12:00:00Z","modified_on":"2021-06-01T12:00:00Z"`,
+		},
+		{
+			name:         "create duplicate",
+			method:       http.MethodPost,
+			path:         accountsPath,
+			body:         createBody(id, "fake account", "GB"),
+			expectStatus: http.StatusConflict,
+			expectBody:   `{"error_message":"Account cannot be created as it violates a duplicate constraint"}`,
+		},
+		{
+			name:         "create without name and country",
+			method:       http.MethodPost,
+			path:         accountsPath,
+			body:         `{"data":{"id":"` + uuid.New().String() + `","organisation_id":"` + uuid.New().String() + `","type":"accounts","attributes":{}}}`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"error_message":"validation failure list:\nname in body is required\ncountry in body is required"}`,
+		},
+		{
+			name:         "create malformed",
+			method:       http.MethodPost,
+			path:         accountsPath,
+			body:         `{"data":`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"error_message":"invalid request body"}`,
+		},
+		{
+			name:         "fetch",
+			method:       http.MethodGet,
+			path:         accountsPath + "/" + id,
+			expectStatus: http.StatusOK,
+			expectBody:   `"links":{"self":"/v1/organisation/accounts/` + id + `"}`,
+		},
+		{
+			name:         "fetch missing",
+			method:       http.MethodGet,
+			path:         accountsPath + "/" + missingID,
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"error_message":"record ` + missingID + ` does not exist"}`,
+		},
+		{
+			name:         "fetch invalid id",
+			method:       http.MethodGet,
+			path:         accountsPath + "/not-uuid",
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"error_message":"id is not a valid uuid"}`,
+		},
+		{
+			name:         "patch wrong version",
+			method:       http.MethodPatch,
+			path:         accountsPath + "/" + id,
+			body:         `{"data":{"id":"` + id + `","type":"accounts","version":3,"attributes":{"name":["renamed"]}}}`,
+			expectStatus: http.StatusConflict,
+			expectBody:   `"error_message":"invalid version","data":{`,
+		},
+		{
+			name:         "patch",
+			method:       http.MethodPatch,
+			path:         accountsPath + "/" + id,
+			body:         `{"data":{"id":"` + id + `","type":"accounts","version":0,"attributes":{"name":["renamed"],"status":"closed"}}}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `"attributes":{"account_number":"41426819","bank_id":"400300","country":"GB","name":["renamed"],"status":"closed"}`,
+		},
+		{
+			name:         "delete without version",
+			method:       http.MethodDelete,
+			path:         accountsPath + "/" + id,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `{"error_message":"invalid version number"}`,
+		},
+		{
+			name:         "delete wrong version",
+			method:       http.MethodDelete,
+			path:         accountsPath + "/" + id + "?version=0",
+			expectStatus: http.StatusConflict,
+			expectBody:   `{"error_message":"invalid version"}`,
+		},
+		{
+			name:         "delete",
+			method:       http.MethodDelete,
+			path:         accountsPath + "/" + id + "?version=1",
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name:         "delete missing",
+			method:       http.MethodDelete,
+			path:         accountsPath + "/" + id + "?version=1",
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:         "unsupported method",
+			method:       http.MethodPut,
+			path:         accountsPath,
+			expectStatus: http.StatusMethodNotAllowed,
+			expectBody:   `{"error_message":"method not allowed"}`,
+		},
+		{
+			name:         "unknown route",
+			method:       http.MethodGet,
+			path:         "/v1/organisation/other",
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"error_message":"route not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := do(t, srv, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.expectStatus, status, body)
+			assert.Contains(t, body, tt.expectBody)
+		})
+	}
+}
+
+func TestAPI_List(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	for i, country := range []string{"GB", "FR", "GB", "GB"} {
+		status, body := do(t, srv, http.MethodPost, accountsPath, createBody(uuid.New().String(), fmt.Sprintf("account %d", i), country))
+		require.Equal(t, http.StatusCreated, status, body)
+	}
+
+	list := func(query string) (int, form.AccountsPage) {
+		status, body := do(t, srv, http.MethodGet, accountsPath+query, "")
+		var page form.AccountsPage
+		if status == http.StatusOK {
+			require.NoError(t, json.Unmarshal([]byte(body), &page))
+		}
+		return status, page
+	}
+
+	status, page := list("")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, page.Data, 4)
+	assert.Empty(t, page.Links.Next)
+
+	status, page = list("?page[number]=1&page[size]=1&filter[country]=GB")
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, []string{"account 2"}, page.Data[0].Attributes.Name)
+	assert.Equal(t, form.Links{
+		First: "/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=0&page%5Bsize%5D=1",
+		Last:  "/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=2&page%5Bsize%5D=1",
+		Next:  "/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=2&page%5Bsize%5D=1",
+		Prev:  "/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=0&page%5Bsize%5D=1",
+		Self:  "/v1/organisation/accounts?filter%5Bcountry%5D=GB&page%5Bnumber%5D=1&page%5Bsize%5D=1",
+	}, page.Links)
+
+	customerBody := `{"data":{"id":"%s","organisation_id":"%s","type":"accounts","attributes":{"name":["customer account"],"country":"GB","customer_id":"customer-1"}}}`
+	status, body := do(t, srv, http.MethodPost, accountsPath, fmt.Sprintf(customerBody, uuid.New().String(), uuid.New().String()))
+	require.Equal(t, http.StatusCreated, status, body)
+	status, page = list("?filter[customer_id]=customer-1")
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, []string{"customer account"}, page.Data[0].Attributes.Name)
+	_, page = list("?filter[customer_id]=customer-2")
+	assert.Empty(t, page.Data)
+
+	status, page = list("?page[number]=5")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotNil(t, page.Data)
+	assert.Empty(t, page.Data)
+
+	status, _ = list("?page[size]=0")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	srv.API.Reset()
+	_, page = list("")
+	assert.Empty(t, page.Data)
+}
+
+//TestAPI_ConcurrentListAndPatch is meant to be run with -race, listed accounts mustn't be shared with patches
+func TestAPI_ConcurrentListAndPatch(t *testing.T) {
+	api := NewAPI()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	id := uuid.New().String()
+	rec := serve(http.MethodPost, accountsPath, createBody(id, "account", "GB"))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for version := 0; version < 100; version++ {
+			patch := fmt.Sprintf(`{"data":{"id":"%s","type":"accounts","version":%d,"attributes":{"name":["account %d"]}}}`, id, version, version)
+			rec := serve(http.MethodPatch, accountsPath+"/"+id, patch)
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			rec := serve(http.MethodGet, accountsPath, "")
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,385 @@
+package formtest
+
+import (
+	"context"
+	"github.com/Gobonoid/form"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+//RunAccountContract runs conformance suite of form accounts API against API reached by HTTPClient returned by newClient.
+//The same suite is run against the real API, the fake one and proxies in front of them so that any drift between them
+//is caught. newClient is called once per subtest, the suite only creates accounts with random IDs and organisations
+//and never assumes the API is empty so that it can be run against shared environments. Requests which AccountAPIClient
+//rejects before sending them aren't part of the suite as they don't depend on the API.
+func RunAccountContract(t *testing.T, newClient func(t *testing.T) form.HTTPClient) {
+	contract := []struct {
+		name string
+		run  func(t *testing.T, accounts *form.AccountAPIClient)
+	}{
+		{name: "create", run: contractCreate},
+		{name: "create normalises bic", run: contractCreateNormalisesBic},
+		{name: "create or get", run: contractCreateOrGet},
+		{name: "fetch", run: contractFetch},
+		{name: "delete", run: contractDelete},
+		{name: "patch", run: contractPatch},
+		{name: "list", run: contractList},
+		{name: "iterate", run: contractIterate},
+	}
+	for _, c := range contract {
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, form.NewAccountAPIClient(newClient(t)))
+		})
+	}
+}
+
+func contractCreate(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		expectErrType error
+		account       form.CreateAccountReq
+		setup         func(accountID string)
+	}{
+		{
+			name:    "duplicate transaction",
+			account: newCreateAccountReq("fake account"),
+			setup: func(accountID string) {
+				req := newCreateAccountReq("fake account")
+				req.ID = accountID
+				require.NoError(t, accounts.CreateAccount(ctx, req))
+			},
+			expectErrType: form.ErrConflict{},
+		},
+		{
+			name:          "bad request",
+			account:       newCreateAccountReq(),
+			expectErrType: form.ErrBadRequest{},
+		},
+		{
+			name:    "success",
+			account: newCreateAccountReq("fake account"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.account.ID)
+			}
+			createdAccount, err := accounts.CreateAccountWithResult(ctx, tt.account)
+			if tt.expectErrType != nil {
+				assert.IsType(t, tt.expectErrType, err)
+				assert.Nil(t, createdAccount)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.account.ID, createdAccount.ID)
+			assert.Equal(t, tt.account.OrganisationID, createdAccount.OrganisationID)
+			assert.Equal(t, tt.account.Type, createdAccount.Type)
+			assert.EqualValues(t, tt.account.Attributes, createdAccount.Attributes)
+			require.NotNil(t, createdAccount.Version)
+			assert.EqualValues(t, 0, *createdAccount.Version)
+			assert.False(t, createdAccount.CreatedOn.IsZero())
+			assert.False(t, createdAccount.ModifiedOn.IsZero())
+
+			fetchedAccount, err := accounts.FetchAccountByID(ctx, tt.account.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, createdAccount, fetchedAccount)
+		})
+	}
+}
+
+func contractCreateNormalisesBic(t *testing.T, accounts *form.AccountAPIClient) {
+	req := newCreateAccountReq("fake account")
+	req.Attributes.Bic = " nwbkgb22xxx "
+	created, err := accounts.CreateAccountWithResult(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "NWBKGB22", created.Attributes.Bic)
+	assert.Equal(t, " nwbkgb22xxx ", req.Attributes.Bic)
+}
+
+func contractCreateOrGet(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		expectErrType error
+		account       form.CreateAccountReq
+		setup         func(req form.CreateAccountReq)
+	}{
+		{
+			name:    "account doesn't exist",
+			account: newCreateAccountReq("fake account"),
+		},
+		{
+			name:    "equivalent account exists",
+			account: newCreateAccountReq("fake account"),
+			setup: func(req form.CreateAccountReq) {
+				require.NoError(t, accounts.CreateAccount(ctx, req))
+			},
+		},
+		{
+			name:          "different account exists",
+			expectErrType: form.ErrConflictingAccount{},
+			account:       newCreateAccountReq("fake account"),
+			setup: func(req form.CreateAccountReq) {
+				req.Attributes = ValidGBAttributes("another fake account")
+				require.NoError(t, accounts.CreateAccount(ctx, req))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.account)
+			}
+			account, err := accounts.CreateOrGetAccount(ctx, tt.account)
+			if tt.expectErrType != nil {
+				assert.IsType(t, tt.expectErrType, err)
+				assert.Nil(t, account)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.account.ID, account.ID)
+			assert.EqualValues(t, tt.account.Attributes, account.Attributes)
+		})
+	}
+}
+
+func contractFetch(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		expectErrType error
+		accountID     string
+	}{
+		{
+			name:          "no account exists",
+			expectErrType: form.ErrNotFound{},
+			accountID:     uuid.New().String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := accounts.FetchAccountByID(ctx, tt.accountID)
+			assert.IsType(t, tt.expectErrType, err)
+			assert.Nil(t, account)
+		})
+	}
+}
+
+func contractDelete(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		expectErrType error
+		accountID     string
+		version       int64
+		setup         func(accountID string)
+	}{
+		{
+			name:          "no account exists",
+			expectErrType: form.ErrNotFound{},
+			accountID:     uuid.New().String(),
+		},
+		{
+			name:          "version conflict",
+			expectErrType: form.ErrConflict{},
+			accountID:     uuid.New().String(),
+			version:       5,
+			setup: func(accountID string) {
+				createAccount(t, accounts, accountID)
+			},
+		},
+		{
+			name:      "success",
+			accountID: uuid.New().String(),
+			setup: func(accountID string) {
+				createAccount(t, accounts, accountID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.accountID)
+			}
+			err := accounts.DeleteAccountByID(ctx, tt.accountID, tt.version)
+			if tt.expectErrType != nil {
+				assert.IsType(t, tt.expectErrType, err)
+				return
+			}
+			require.NoError(t, err)
+			_, err = accounts.FetchAccountByID(ctx, tt.accountID)
+			assert.IsType(t, form.ErrNotFound{}, err)
+			assert.IsType(t, form.ErrNotFound{}, accounts.DeleteAccountByID(ctx, tt.accountID, tt.version))
+		})
+	}
+}
+
+func contractPatch(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+	closedStatus := "closed"
+
+	tests := []struct {
+		name          string
+		expectErrType error
+		accountID     string
+		version       int64
+		setup         func(accountID string)
+	}{
+		{
+			name:          "no account exists",
+			expectErrType: form.ErrNotFound{},
+			accountID:     uuid.New().String(),
+		},
+		{
+			name:          "version conflict",
+			expectErrType: form.ErrConflict{},
+			accountID:     uuid.New().String(),
+			version:       5,
+			setup: func(accountID string) {
+				createAccount(t, accounts, accountID)
+			},
+		},
+		{
+			name:      "success",
+			accountID: uuid.New().String(),
+			setup: func(accountID string) {
+				createAccount(t, accounts, accountID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(tt.accountID)
+			}
+			patched, err := accounts.PatchAccount(ctx, tt.accountID, tt.version, form.AccountAttributes{
+				Name:   []string{"renamed account"},
+				Status: &closedStatus,
+			})
+			if tt.expectErrType != nil {
+				assert.IsType(t, tt.expectErrType, err)
+				assert.Nil(t, patched)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.accountID, patched.ID)
+			assert.Equal(t, []string{"renamed account"}, patched.Attributes.Name)
+			assert.Equal(t, closedStatus, *patched.Attributes.Status)
+			assert.Equal(t, "GB", *patched.Attributes.Country)
+			assert.Greater(t, *patched.Version, tt.version)
+
+			fetched, err := accounts.FetchAccountByID(ctx, tt.accountID)
+			require.NoError(t, err)
+			assert.Equal(t, patched.Attributes, fetched.Attributes)
+			assert.Equal(t, patched.Version, fetched.Version)
+		})
+	}
+}
+
+func contractList(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		expectErrType error
+		opts          form.ListAccountsOpts
+	}{
+		{
+			name: "success",
+			opts: form.ListAccountsOpts{PageSize: 1},
+		},
+		{
+			name: "filter account_number alone",
+			opts: form.ListAccountsOpts{PageSize: 1, Filter: &form.AccountFilter{AccountNumber: "41426819"}},
+		},
+		{
+			name: "filter bank_id_code alone",
+			opts: form.ListAccountsOpts{PageSize: 1, Filter: &form.AccountFilter{BankIDCode: "GBDSC"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expectErrType == nil {
+				createAccount(t, accounts, uuid.New().String())
+			}
+			page, err := accounts.ListAccounts(ctx, tt.opts)
+			if tt.expectErrType != nil {
+				assert.IsType(t, tt.expectErrType, err)
+				assert.Nil(t, page)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, page.Data, 1)
+			assert.NotEmpty(t, page.Links.First)
+			assert.NotEmpty(t, page.Links.Last)
+		})
+	}
+}
+
+func contractIterate(t *testing.T, accounts *form.AccountAPIClient) {
+	ctx := context.Background()
+
+	//accounts of the organisation are walked across pages without going through the rest of the API
+	organisationID := uuid.New().String()
+	created := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		req := newCreateAccountReq("fake account")
+		req.OrganisationID = organisationID
+		require.NoError(t, accounts.CreateAccount(ctx, req))
+		created[req.ID] = true
+	}
+
+	iterated := map[string]bool{}
+	it := accounts.IterateAccounts(ctx, form.ListAccountsOpts{
+		PageSize: 2,
+		Filter:   &form.AccountFilter{OrganisationID: organisationID},
+	})
+	for it.Next() {
+		iterated[it.Account().ID] = true
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, created, iterated)
+}
+
+func createAccount(t *testing.T, accounts *form.AccountAPIClient, accountID string) {
+	req := newCreateAccountReq("fake account")
+	req.ID = accountID
+	require.NoError(t, accounts.CreateAccount(context.Background(), req))
+}
+
+func newCreateAccountReq(name ...string) form.CreateAccountReq {
+	return form.CreateAccountReq{
+		Attributes:     ValidGBAttributes(name...),
+		ID:             uuid.New().String(),
+		OrganisationID: uuid.New().String(),
+		Type:           "accounts",
+	}
+}
+
+//ValidGBAttributes returns attributes of GB account passing validation of AccountAPIClient and the API, account without
+//name is rejected by the API only
+func ValidGBAttributes(name ...string) *form.AccountAttributes {
+	gbCountryCode := "GB"
+	return &form.AccountAttributes{
+		AccountNumber: "41426819",
+		BankID:        "400300",
+		BankIDCode:    "GBDSC",
+		Bic:           "NWBKGB22",
+		Country:       &gbCountryCode,
+		Name:          name,
+	}
+}
@@ -0,0 +1,24 @@
+package formtest_test
+
+import (
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/client"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+//TestRunAccountContract_RetriedFaults checks that the contract holds when the API is flaky but the client retries
+func TestRunAccountContract_RetriedFaults(t *testing.T) {
+	srv := formtest.NewServer()
+	defer srv.Close()
+	require.NoError(t, srv.SetScenario(formtest.Scenario{Faults: []formtest.Fault{
+		{Method: http.MethodGet, Every: 3, Status: http.StatusServiceUnavailable},
+	}}))
+	formtest.RunAccountContract(t, func(t *testing.T) form.HTTPClient {
+		c, err := client.NewDefaultClient(srv.URL, client.WithHTTPClient(srv.Client()), client.WithRetryPolicy(client.DefaultRetryPolicy()))
+		require.NoError(t, err)
+		return c
+	})
+}
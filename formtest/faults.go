@@ -0,0 +1,233 @@
+package formtest
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//FaultInjector is http.Handler injecting faults of the current scenario into requests handled by next
+type FaultInjector struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	scenario Scenario
+	selected []int
+	affected []int
+	rand     *rand.Rand
+}
+
+//NewFaultInjector creates FaultInjector without any scenario i.e. passing all requests to next untouched
+func NewFaultInjector(next http.Handler) *FaultInjector {
+	return &FaultInjector{
+		next: next,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//SetScenario replaces current scenario and resets request counters, it's safe to be called while requests are handled
+func (f *FaultInjector) SetScenario(sc Scenario) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scenario = sc
+	f.selected = make([]int, len(sc.Faults))
+	f.affected = make([]int, len(sc.Faults))
+	return nil
+}
+
+//Scenario returns current scenario
+func (f *FaultInjector) Scenario() Scenario {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.scenario
+}
+
+//ServeHTTP as in http.Handler interface implementation
+func (f *FaultInjector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	latency, fault, ok := f.faultsFor(r)
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if !ok {
+		f.next.ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case fault.Reset:
+		reset(w)
+	case fault.Status != 0:
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Duration(fault.RetryAfter).Seconds()))))
+		}
+		body := fault.Body
+		if body == "" {
+			body = fmt.Sprintf(`{"error_message":%q}`, http.StatusText(fault.Status))
+		}
+		w.Header().Set(requestIDHeader, uuid.New().String())
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(fault.Status)
+		_, _ = w.Write([]byte(body))
+	case fault.Malformed:
+		rec := httptest.NewRecorder()
+		f.next.ServeHTTP(rec, r)
+		body := rec.Body.Bytes()
+		//statuses like 204 to DELETE can't carry body so there's nothing to break
+		if len(body) == 0 || !bodyAllowed(r, rec.Code) {
+			writeRecorded(w, rec, body)
+			return
+		}
+		//cutting JSON in half always leaves it unterminated
+		writeRecorded(w, rec, append(body[:len(body)/2:len(body)/2], '{'))
+	case fault.SlowDrip != nil:
+		rec := httptest.NewRecorder()
+		f.next.ServeHTTP(rec, r)
+		drip(w, r, rec, *fault.SlowDrip)
+	default:
+		f.next.ServeHTTP(w, r)
+	}
+}
+
+//faultsFor sums latency of all faults affecting the request and returns the first one deciding the response
+func (f *FaultInjector) faultsFor(r *http.Request) (time.Duration, Fault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var latency time.Duration
+	var response Fault
+	var found bool
+	for i, fault := range f.scenario.Faults {
+		if !fault.selects(r) {
+			continue
+		}
+		f.selected[i]++
+		n := f.selected[i]
+		if (fault.Nth > 0 && n != fault.Nth) ||
+			(fault.Every > 0 && n%fault.Every != 0) ||
+			(fault.Times > 0 && f.affected[i] >= fault.Times) ||
+			(fault.Probability > 0 && f.rand.Float64() >= fault.Probability) {
+			continue
+		}
+		f.affected[i]++
+		if fault.Latency != nil {
+			latency += fault.Latency.draw(f.rand)
+		}
+		if !found && (fault.Reset || fault.Status != 0 || fault.Malformed || fault.SlowDrip != nil) {
+			response, found = fault, true
+		}
+	}
+	return latency, response, found
+}
+
+func (fault Fault) selects(r *http.Request) bool {
+	if fault.Method != "" && fault.Method != r.Method {
+		return false
+	}
+	if fault.Path != "" {
+		if ok, err := path.Match(fault.Path, r.URL.Path); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (l Latency) draw(rnd *rand.Rand) time.Duration {
+	var d float64
+	switch l.Distribution {
+	case DistributionFixed:
+		d = float64(l.Mean)
+	case DistributionUniform:
+		d = float64(l.Min) + rnd.Float64()*float64(l.Max-l.Min)
+	case DistributionNormal:
+		d = rnd.NormFloat64()*float64(l.StdDev) + float64(l.Mean)
+	case DistributionExponential:
+		d = rnd.ExpFloat64() * float64(l.Mean)
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+//reset closes connection abruptly so that client sees connection reset instead of any response
+func reset(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = conn.Close()
+}
+
+//bodyAllowed reports whether response to r with given status may have body
+func bodyAllowed(r *http.Request, status int) bool {
+	switch {
+	case r.Method == http.MethodHead, status < http.StatusOK, status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+func writeRecorded(w http.ResponseWriter, rec *httptest.ResponseRecorder, body []byte) {
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	//responses without body mustn't have Content-Length e.g. 204
+	if len(body) > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.WriteHeader(rec.Code)
+	_, _ = w.Write(body)
+}
+
+//drip sends recorded body in chunks, Content-Length is kept so that client waits for the whole body
+func drip(w http.ResponseWriter, r *http.Request, rec *httptest.ResponseRecorder, sd SlowDrip) {
+	body := rec.Body.Bytes()
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(rec.Code)
+	flusher, _ := w.(http.Flusher)
+	for len(body) > 0 {
+		n := sd.ChunkSize
+		if n > len(body) {
+			n = len(body)
+		}
+		if _, err := w.Write(body[:n]); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		body = body[n:]
+		if len(body) == 0 {
+			return
+		}
+		select {
+		case <-time.After(time.Duration(sd.Interval)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package formtest
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFaultInjector(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	t.Run("fixed latency", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{
+			{Path: healthPath, Latency: &Latency{Distribution: DistributionFixed, Mean: Duration(50 * time.Millisecond)}},
+		}}))
+		start := time.Now()
+		status, _ := do(t, srv, http.MethodGet, healthPath, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(50*time.Millisecond))
+	})
+
+	t.Run("connection reset", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{{Path: healthPath, Reset: true}}}))
+		resp, err := srv.Client().Get(srv.URL + healthPath)
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+
+	t.Run("nth request fails", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{{Path: healthPath, Nth: 2, Status: http.StatusServiceUnavailable}}}))
+		var statuses []int
+		for i := 0; i < 3; i++ {
+			status, _ := do(t, srv, http.MethodGet, healthPath, "")
+			statuses = append(statuses, status)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusOK}, statuses)
+	})
+
+	t.Run("status per route", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{
+			{Method: http.MethodGet, Path: accountsPath + "/*", Status: http.StatusInternalServerError, Body: `{"error_message":"boom"}`},
+		}}))
+		status, body := do(t, srv, http.MethodGet, accountsPath+"/"+uuid.New().String(), "")
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, `{"error_message":"boom"}`, body)
+
+		status, _ = do(t, srv, http.MethodGet, accountsPath, "")
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("429 with retry after", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{
+			{Path: accountsPath, Times: 1, Status: http.StatusTooManyRequests, RetryAfter: Duration(1500 * time.Millisecond)},
+		}}))
+		resp, err := srv.Client().Get(srv.URL + accountsPath)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+		status, _ := do(t, srv, http.MethodGet, accountsPath, "")
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{{Path: healthPath, Malformed: true}}}))
+		status, body := do(t, srv, http.MethodGet, healthPath, "")
+		assert.Equal(t, http.StatusOK, status)
+		var v interface{}
+		assert.Error(t, json.Unmarshal([]byte(body), &v))
+	})
+
+	t.Run("malformed body of response without body", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{}))
+		id := uuid.New().String()
+		status, body := do(t, srv, http.MethodPost, accountsPath, createBody(id, "fake account", "GB"))
+		require.Equal(t, http.StatusCreated, status, body)
+
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{{Method: http.MethodDelete, Path: accountsPath + "/*", Malformed: true}}}))
+		//recorder shows what the fault writes, the server would silently drop body of 204
+		rec := httptest.NewRecorder()
+		srv.Faults.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, accountsPath+"/"+id+"?version=0", nil))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Empty(t, rec.Header().Get("Content-Length"))
+		status, _ = do(t, srv, http.MethodGet, accountsPath+"/"+id, "")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("slow drip", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{Faults: []Fault{
+			{Path: healthPath, SlowDrip: &SlowDrip{ChunkSize: 4, Interval: Duration(10 * time.Millisecond)}},
+		}}))
+		start := time.Now()
+		status, body := do(t, srv, http.MethodGet, healthPath, "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.JSONEq(t, `{"status":"up"}`, body)
+		//16 bytes long body is sent in 4 chunks with 3 pauses between them
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(30*time.Millisecond))
+	})
+
+	t.Run("empty scenario", func(t *testing.T) {
+		require.NoError(t, srv.SetScenario(Scenario{}))
+		status, _ := do(t, srv, http.MethodGet, healthPath, "")
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("invalid scenario", func(t *testing.T) {
+		err := srv.SetScenario(Scenario{Faults: []Fault{{Probability: 2}}})
+		assert.EqualError(t, err, "fault 0: probability has to be between 0 and 1")
+	})
+}
+
+func TestLatency_Draw(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	tests := []struct {
+		name    string
+		latency Latency
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "fixed",
+			latency: Latency{Distribution: DistributionFixed, Mean: Duration(time.Second)},
+			min:     time.Second,
+			max:     time.Second,
+		},
+		{
+			name:    "uniform",
+			latency: Latency{Distribution: DistributionUniform, Min: Duration(time.Second), Max: Duration(2 * time.Second)},
+			min:     time.Second,
+			max:     2 * time.Second,
+		},
+		{
+			name:    "normal is never negative",
+			latency: Latency{Distribution: DistributionNormal, StdDev: Duration(time.Second)},
+			min:     0,
+			max:     time.Hour,
+		},
+		{
+			name:    "exponential",
+			latency: Latency{Distribution: DistributionExponential, Mean: Duration(time.Second)},
+			min:     0,
+			max:     time.Hour,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				d := test.latency.draw(srv.Faults.rand)
+				assert.GreaterOrEqual(t, int64(d), int64(test.min))
+				assert.LessOrEqual(t, int64(d), int64(test.max))
+			}
+		})
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	expected := Scenario{
+		Name: "flaky accounts",
+		Faults: []Fault{
+			{
+				Method:  http.MethodGet,
+				Path:    accountsPath + "/*",
+				Latency: &Latency{Distribution: DistributionUniform, Min: Duration(10 * time.Millisecond), Max: Duration(50 * time.Millisecond)},
+			},
+			{Method: http.MethodPost, Path: accountsPath, Every: 3, Status: http.StatusServiceUnavailable},
+			{Path: accountsPath, Times: 1, Status: http.StatusTooManyRequests, RetryAfter: Duration(2 * time.Second)},
+			{Path: accountsPath + "/*", Probability: 0.1, SlowDrip: &SlowDrip{ChunkSize: 16, Interval: Duration(5 * time.Millisecond)}},
+		},
+	}
+	tests := []struct {
+		name        string
+		path        string
+		expectError string
+	}{
+		{name: "yaml", path: "testdata/scenario.yaml"},
+		{name: "json", path: "testdata/scenario.json"},
+		{name: "unknown field", path: "testdata/unknown_field.yaml", expectError: "failed to decode scenario: testdata/unknown_field.yaml"},
+		{name: "missing file", path: "testdata/missing.yaml", expectError: "failed to read scenario: testdata/missing.yaml"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sc, err := LoadScenario(test.path)
+			if test.expectError != "" {
+				require.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), test.expectError), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expected, sc)
+		})
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	b, err := json.Marshal(Fault{RetryAfter: Duration(1500 * time.Millisecond)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"retry_after":"1.5s"}`, string(b))
+
+	var f Fault
+	assert.Error(t, json.Unmarshal([]byte(`{"retry_after":15}`), &f))
+}
@@ -0,0 +1,177 @@
+package formtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//Latency distributions
+const (
+	DistributionFixed       = "fixed"
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+)
+
+//Scenario is a named list of faults injected into requests handled by the fake API
+type Scenario struct {
+	Name   string  `json:"name" yaml:"name"`
+	Faults []Fault `json:"faults" yaml:"faults"`
+}
+
+//Fault describes which requests are affected and how. Method and Path select requests, Path is a path.Match pattern
+//e.g. /v1/organisation/accounts/*. Nth, Every, Times and Probability narrow down which of selected requests are affected,
+//all of them are ignored when zero. Latency is added to every affected request while only the first affected fault
+//defining Status, Reset, Malformed or SlowDrip decides the response.
+type Fault struct {
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	Path   string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	//Nth affects only the n-th selected request, counting from 1
+	Nth int `json:"nth,omitempty" yaml:"nth,omitempty"`
+	//Every affects every n-th selected request
+	Every int `json:"every,omitempty" yaml:"every,omitempty"`
+	//Times limits how many requests are affected in total
+	Times int `json:"times,omitempty" yaml:"times,omitempty"`
+	//Probability of the selected request being affected, between 0 and 1
+	Probability float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+
+	//Latency delays the request before it's handled
+	Latency *Latency `json:"latency,omitempty" yaml:"latency,omitempty"`
+	//Status is returned instead of calling the API, Body defaults to error_message with status text
+	Status int    `json:"status,omitempty" yaml:"status,omitempty"`
+	Body   string `json:"body,omitempty" yaml:"body,omitempty"`
+	//RetryAfter is sent in Retry-After header along with Status e.g. 429
+	RetryAfter Duration `json:"retry_after,omitempty" yaml:"retry_after,omitempty"`
+	//Reset closes connection without any response
+	Reset bool `json:"reset,omitempty" yaml:"reset,omitempty"`
+	//Malformed truncates JSON body returned by the API, responses without body e.g. 204 to DELETE are left as they are
+	Malformed bool `json:"malformed,omitempty" yaml:"malformed,omitempty"`
+	//SlowDrip sends body returned by the API in small chunks
+	SlowDrip *SlowDrip `json:"slow_drip,omitempty" yaml:"slow_drip,omitempty"`
+}
+
+//Latency is a distribution delays are drawn from. Fixed uses Mean, uniform draws between Min and Max,
+//normal uses Mean and StdDev and exponential uses Mean. Drawn delays are never negative.
+type Latency struct {
+	Distribution string   `json:"distribution" yaml:"distribution"`
+	Min          Duration `json:"min,omitempty" yaml:"min,omitempty"`
+	Max          Duration `json:"max,omitempty" yaml:"max,omitempty"`
+	Mean         Duration `json:"mean,omitempty" yaml:"mean,omitempty"`
+	StdDev       Duration `json:"std_dev,omitempty" yaml:"std_dev,omitempty"`
+}
+
+//SlowDrip sends ChunkSize bytes of the body every Interval
+type SlowDrip struct {
+	ChunkSize int      `json:"chunk_size" yaml:"chunk_size"`
+	Interval  Duration `json:"interval" yaml:"interval"`
+}
+
+//Duration is time.Duration read from and written as string e.g. 150ms
+type Duration time.Duration
+
+//MarshalJSON as in json.Marshaler interface implementation
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+//UnmarshalJSON as in json.Unmarshaler interface implementation
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "duration has to be a string e.g. 150ms")
+	}
+	return d.parse(s)
+}
+
+//MarshalYAML as in yaml.Marshaler interface implementation
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+//UnmarshalYAML as in yaml.Unmarshaler interface implementation
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return errors.Wrap(err, "duration has to be a string e.g. 150ms")
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration %s", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+//LoadScenario reads scenario from JSON file or YAML file (.yaml or .yml), unknown fields are rejected
+func LoadScenario(path string) (Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, errors.Wrapf(err, "failed to read scenario: %s", path)
+	}
+	var sc Scenario
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&sc)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		err = dec.Decode(&sc)
+	default:
+		return Scenario{}, errors.Errorf("unsupported scenario file extension: %s", path)
+	}
+	if err != nil {
+		return Scenario{}, errors.Wrapf(err, "failed to decode scenario: %s", path)
+	}
+	if err = sc.Validate(); err != nil {
+		return Scenario{}, errors.Wrapf(err, "invalid scenario: %s", path)
+	}
+	return sc, nil
+}
+
+//Validate checks that every fault is well defined
+func (sc Scenario) Validate() error {
+	for i, f := range sc.Faults {
+		if err := f.validate(); err != nil {
+			return errors.Wrapf(err, "fault %d", i)
+		}
+	}
+	return nil
+}
+
+func (f Fault) validate() error {
+	switch {
+	case f.Nth < 0 || f.Every < 0 || f.Times < 0:
+		return errors.New("nth, every and times can't be negative")
+	case f.Probability < 0 || f.Probability > 1:
+		return errors.New("probability has to be between 0 and 1")
+	case f.Status != 0 && (f.Status < 100 || f.Status > 599):
+		return errors.Errorf("invalid status %d", f.Status)
+	case f.SlowDrip != nil && (f.SlowDrip.ChunkSize <= 0 || f.SlowDrip.Interval < 0):
+		return errors.New("slow drip requires positive chunk size")
+	}
+	if f.Latency != nil {
+		switch f.Latency.Distribution {
+		case DistributionFixed, DistributionNormal, DistributionExponential:
+		case DistributionUniform:
+			if f.Latency.Max < f.Latency.Min {
+				return errors.New("uniform latency max can't be lower than min")
+			}
+		default:
+			return errors.Errorf("unknown latency distribution %q", f.Latency.Distribution)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package formtest
+
+import (
+	"net/http/httptest"
+)
+
+//Server is a fake form accounts API listening on local loopback, its URL can be passed directly to client.NewDefaultClient
+type Server struct {
+	*httptest.Server
+	API    *API
+	Faults *FaultInjector
+}
+
+//NewServer starts Server without any accounts and faults, it has to be closed by the caller
+func NewServer() *Server {
+	api := NewAPI()
+	faults := NewFaultInjector(api)
+	return &Server{
+		Server: httptest.NewServer(faults),
+		API:    api,
+		Faults: faults,
+	}
+}
+
+//SetScenario replaces faults injected into requests, empty Scenario turns them off
+func (s *Server) SetScenario(sc Scenario) error {
+	return s.Faults.SetScenario(sc)
+}
@@ -8,4 +8,5 @@ require (
 	github.com/jarcoal/httpmock v1.0.8
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,172 @@
+package iban
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//span of BBAN characters [start, end)
+type span [2]int
+
+func (s span) of(bban string) string {
+	return bban[s[0]:s[1]]
+}
+
+type spec struct {
+	//bban structure in SWIFT IBAN registry notation e.g. 4!a6!n8!n
+	bban    string
+	bank    span
+	branch  span
+	account span
+	//national overrides span of NationalBankID, bank and branch codes are used when not set
+	national span
+
+	once sync.Once
+	re   *regexp.Regexp
+	n    int
+}
+
+func (sp *spec) compile() {
+	sp.once.Do(func() {
+		var b strings.Builder
+		b.WriteString("^")
+		for _, m := range regexp.MustCompile(`(\d+)!([nac])`).FindAllStringSubmatch(sp.bban, -1) {
+			n, _ := strconv.Atoi(m[1])
+			sp.n += n
+			switch m[2] {
+			case "n":
+				fmt.Fprintf(&b, "[0-9]{%d}", n)
+			case "a":
+				fmt.Fprintf(&b, "[A-Z]{%d}", n)
+			case "c":
+				fmt.Fprintf(&b, "[A-Z0-9]{%d}", n)
+			}
+		}
+		b.WriteString("$")
+		sp.re = regexp.MustCompile(b.String())
+	})
+}
+
+func (sp *spec) pattern() *regexp.Regexp {
+	sp.compile()
+	return sp.re
+}
+
+func (sp *spec) bbanLength() int {
+	sp.compile()
+	return sp.n
+}
+
+func (sp *spec) length() int {
+	return 4 + sp.bbanLength()
+}
+
+//gaps returns positions of BBAN characters which belong to neither bank, branch nor account span
+func (sp *spec) gaps() []int {
+	var gaps []int
+	for i := 0; i < sp.bbanLength(); i++ {
+		covered := false
+		for _, s := range []span{sp.bank, sp.branch, sp.account} {
+			covered = covered || (i >= s[0] && i < s[1])
+		}
+		if !covered {
+			gaps = append(gaps, i)
+		}
+	}
+	return gaps
+}
+
+func (sp *spec) iban(countryCode, checkDigits, bban string) *IBAN {
+	national := sp.national
+	if national == (span{}) {
+		national = span{sp.bank[0], sp.bank[1]}
+		if sp.branch != (span{}) {
+			national[1] = sp.branch[1]
+		}
+	}
+	return &IBAN{
+		CountryCode:    countryCode,
+		CheckDigits:    checkDigits,
+		BBAN:           bban,
+		BankCode:       sp.bank.of(bban),
+		BranchCode:     sp.branch.of(bban),
+		AccountNumber:  sp.account.of(bban),
+		NationalBankID: national.of(bban),
+	}
+}
+
+//specs as published in SWIFT IBAN registry, covering SEPA countries and major non-SEPA IBAN countries
+var specs = map[string]*spec{
+	"AD": {bban: "4!n4!n12!c", bank: span{0, 4}, branch: span{4, 8}, account: span{8, 20}},
+	"AE": {bban: "3!n16!n", bank: span{0, 3}, account: span{3, 19}},
+	"AL": {bban: "8!n16!c", bank: span{0, 3}, branch: span{3, 7}, account: span{8, 24}, national: span{0, 8}},
+	"AT": {bban: "5!n11!n", bank: span{0, 5}, account: span{5, 16}},
+	"AZ": {bban: "4!a20!c", bank: span{0, 4}, account: span{4, 24}},
+	"BA": {bban: "3!n3!n8!n2!n", bank: span{0, 3}, branch: span{3, 6}, account: span{6, 14}},
+	"BE": {bban: "3!n7!n2!n", bank: span{0, 3}, account: span{3, 10}},
+	"BG": {bban: "4!a4!n2!n8!c", bank: span{0, 4}, branch: span{4, 8}, account: span{10, 18}},
+	"BH": {bban: "4!a14!c", bank: span{0, 4}, account: span{4, 18}},
+	"BR": {bban: "8!n5!n10!n1!a1!c", bank: span{0, 8}, branch: span{8, 13}, account: span{13, 23}},
+	"CH": {bban: "5!n12!c", bank: span{0, 5}, account: span{5, 17}},
+	"CR": {bban: "4!n14!n", bank: span{0, 4}, account: span{4, 18}},
+	"CY": {bban: "3!n5!n16!c", bank: span{0, 3}, branch: span{3, 8}, account: span{8, 24}},
+	"CZ": {bban: "4!n6!n10!n", bank: span{0, 4}, account: span{4, 20}},
+	"DE": {bban: "8!n10!n", bank: span{0, 8}, account: span{8, 18}},
+	"DK": {bban: "4!n9!n1!n", bank: span{0, 4}, account: span{4, 14}},
+	"DO": {bban: "4!c20!n", bank: span{0, 4}, account: span{4, 24}},
+	"EE": {bban: "2!n2!n11!n1!n", bank: span{0, 2}, branch: span{2, 4}, account: span{4, 15}, national: span{0, 2}},
+	"EG": {bban: "4!n4!n17!n", bank: span{0, 4}, branch: span{4, 8}, account: span{8, 25}},
+	"ES": {bban: "4!n4!n1!n1!n10!n", bank: span{0, 4}, branch: span{4, 8}, account: span{10, 20}},
+	"FI": {bban: "3!n11!n", bank: span{0, 3}, account: span{3, 14}},
+	"FO": {bban: "4!n9!n1!n", bank: span{0, 4}, account: span{4, 14}},
+	"FR": {bban: "5!n5!n11!c2!n", bank: span{0, 5}, branch: span{5, 10}, account: span{10, 21}},
+	"GB": {bban: "4!a6!n8!n", bank: span{0, 4}, branch: span{4, 10}, account: span{10, 18}, national: span{4, 10}},
+	"GE": {bban: "2!a16!n", bank: span{0, 2}, account: span{2, 18}},
+	"GI": {bban: "4!a15!c", bank: span{0, 4}, account: span{4, 19}},
+	"GL": {bban: "4!n9!n1!n", bank: span{0, 4}, account: span{4, 14}},
+	"GR": {bban: "3!n4!n16!c", bank: span{0, 3}, branch: span{3, 7}, account: span{7, 23}},
+	"GT": {bban: "4!c20!c", bank: span{0, 4}, account: span{4, 24}},
+	"HR": {bban: "7!n10!n", bank: span{0, 7}, account: span{7, 17}},
+	"HU": {bban: "3!n4!n1!n15!n1!n", bank: span{0, 3}, branch: span{3, 7}, account: span{8, 24}, national: span{0, 8}},
+	"IE": {bban: "4!a6!n8!n", bank: span{0, 4}, branch: span{4, 10}, account: span{10, 18}, national: span{4, 10}},
+	"IL": {bban: "3!n3!n13!n", bank: span{0, 3}, branch: span{3, 6}, account: span{6, 19}},
+	"IS": {bban: "4!n2!n6!n10!n", bank: span{0, 4}, account: span{4, 12}},
+	"IT": {bban: "1!a5!n5!n12!c", bank: span{1, 6}, branch: span{6, 11}, account: span{11, 23}},
+	"JO": {bban: "4!a4!n18!c", bank: span{0, 4}, branch: span{4, 8}, account: span{8, 26}},
+	"KW": {bban: "4!a22!c", bank: span{0, 4}, account: span{4, 26}},
+	"KZ": {bban: "3!n13!c", bank: span{0, 3}, account: span{3, 16}},
+	"LB": {bban: "4!n20!c", bank: span{0, 4}, account: span{4, 24}},
+	"LI": {bban: "5!n12!c", bank: span{0, 5}, account: span{5, 17}},
+	"LT": {bban: "5!n11!n", bank: span{0, 5}, account: span{5, 16}},
+	"LU": {bban: "3!n13!c", bank: span{0, 3}, account: span{3, 16}},
+	"LV": {bban: "4!a13!c", bank: span{0, 4}, account: span{4, 17}},
+	"MC": {bban: "5!n5!n11!c2!n", bank: span{0, 5}, branch: span{5, 10}, account: span{10, 21}},
+	"MD": {bban: "2!c18!c", bank: span{0, 2}, account: span{2, 20}},
+	"ME": {bban: "3!n13!n2!n", bank: span{0, 3}, account: span{3, 16}},
+	"MK": {bban: "3!n10!c2!n", bank: span{0, 3}, account: span{3, 13}},
+	"MT": {bban: "4!a5!n18!c", bank: span{0, 4}, branch: span{4, 9}, account: span{9, 27}},
+	"MU": {bban: "4!a2!n2!n12!n3!n3!a", bank: span{0, 6}, branch: span{6, 8}, account: span{8, 20}},
+	"NL": {bban: "4!a10!n", bank: span{0, 4}, account: span{4, 14}},
+	"NO": {bban: "4!n6!n1!n", bank: span{0, 4}, account: span{4, 11}},
+	"PK": {bban: "4!a16!c", bank: span{0, 4}, account: span{4, 20}},
+	"PL": {bban: "8!n16!n", bank: span{0, 3}, branch: span{3, 7}, account: span{8, 24}, national: span{0, 8}},
+	"PS": {bban: "4!a21!c", bank: span{0, 4}, account: span{4, 25}},
+	"PT": {bban: "4!n4!n11!n2!n", bank: span{0, 4}, branch: span{4, 8}, account: span{8, 19}},
+	"QA": {bban: "4!a21!c", bank: span{0, 4}, account: span{4, 25}},
+	"RO": {bban: "4!a16!c", bank: span{0, 4}, account: span{4, 20}},
+	"RS": {bban: "3!n13!n2!n", bank: span{0, 3}, account: span{3, 16}},
+	"SA": {bban: "2!n18!c", bank: span{0, 2}, account: span{2, 20}},
+	"SE": {bban: "3!n16!n1!n", bank: span{0, 3}, account: span{3, 20}},
+	"SI": {bban: "5!n8!n2!n", bank: span{0, 2}, branch: span{2, 5}, account: span{5, 13}},
+	"SK": {bban: "4!n6!n10!n", bank: span{0, 4}, account: span{4, 20}},
+	"SM": {bban: "1!a5!n5!n12!c", bank: span{1, 6}, branch: span{6, 11}, account: span{11, 23}},
+	"TN": {bban: "2!n3!n13!n2!n", bank: span{0, 2}, branch: span{2, 5}, account: span{5, 18}},
+	"TR": {bban: "5!n1!n16!c", bank: span{0, 5}, account: span{6, 22}},
+	"UA": {bban: "6!n19!c", bank: span{0, 6}, account: span{6, 25}},
+	"VA": {bban: "3!n15!n", bank: span{0, 3}, account: span{3, 18}},
+	"VG": {bban: "4!a16!n", bank: span{0, 4}, account: span{4, 20}},
+	"XK": {bban: "4!n10!n2!n", bank: span{0, 2}, branch: span{2, 4}, account: span{4, 14}},
+}
@@ -0,0 +1,167 @@
+package iban
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	//ErrUnsupportedCountry is returned when country doesn't use IBAN or its format isn't known
+	ErrUnsupportedCountry = errors.New("country isn't supported")
+	//ErrInvalidLength is returned when IBAN length doesn't match length defined for its country
+	ErrInvalidLength = errors.New("invalid length")
+	//ErrInvalidFormat is returned when IBAN contains characters not allowed by its country BBAN structure
+	ErrInvalidFormat = errors.New("invalid format")
+	//ErrInvalidChecksum is returned when IBAN check digits don't match mod-97 checksum
+	ErrInvalidChecksum = errors.New("invalid checksum")
+)
+
+//IBAN holds parsed International Bank Account Number with its components extracted according to the country BBAN structure.
+//Components that aren't defined for the country are left empty.
+type IBAN struct {
+	CountryCode   string
+	CheckDigits   string
+	BBAN          string
+	BankCode      string
+	BranchCode    string
+	AccountNumber string
+	//NationalBankID is the domestic bank identifier as used by form accounts API bank_id e.g. sort code for GB, BLZ for DE
+	NationalBankID string
+}
+
+//Parse accepts IBAN in either electronic or print format, verifies its length, structure and checksum
+func Parse(s string) (*IBAN, error) {
+	s = normalise(s)
+	if len(s) < 4 {
+		return nil, errors.Wrapf(ErrInvalidLength, "%q is too short", s)
+	}
+	countryCode, bban := s[:2], s[4:]
+	sp, ok := specs[countryCode]
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedCountry, countryCode)
+	}
+	if len(s) != sp.length() {
+		return nil, errors.Wrapf(ErrInvalidLength, "%s IBAN has to be %d characters long", countryCode, sp.length())
+	}
+	if !isDigits(s[2:4]) || !sp.pattern().MatchString(bban) {
+		return nil, errors.Wrapf(ErrInvalidFormat, "%s IBAN has to follow %s BBAN structure", countryCode, sp.bban)
+	}
+	if mod97(s) != 1 {
+		return nil, ErrInvalidChecksum
+	}
+	return sp.iban(countryCode, s[2:4], bban), nil
+}
+
+//Account holds domestic account details IBAN is built from. NationalCheck holds BBAN characters which are neither
+//bank code, branch code nor account number e.g. national check digits of ES, CIN of IT or RIB key of FR, in order
+//they appear in BBAN. Components the country doesn't use have to be left empty.
+type Account struct {
+	BankCode      string
+	BranchCode    string
+	AccountNumber string
+	NationalCheck string
+}
+
+//Build creates IBAN from domestic account details computing its check digits, numeric account number shorter than
+//the country's account number is padded with leading zeros. National check characters are never computed, they have
+//to be provided for countries using them.
+func Build(countryCode string, account Account) (*IBAN, error) {
+	countryCode = normalise(countryCode)
+	sp, ok := specs[countryCode]
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedCountry, countryCode)
+	}
+	bban := make([]byte, sp.bbanLength())
+	for _, part := range []struct {
+		name  string
+		value string
+		span  span
+	}{
+		{name: "bank code", value: normalise(account.BankCode), span: sp.bank},
+		{name: "branch code", value: normalise(account.BranchCode), span: sp.branch},
+		{name: "account number", value: normalise(account.AccountNumber), span: sp.account},
+	} {
+		width := part.span[1] - part.span[0]
+		if part.name == "account number" && isDigits(part.value) && len(part.value) < width {
+			part.value = strings.Repeat("0", width-len(part.value)) + part.value
+		}
+		if len(part.value) != width {
+			return nil, errors.Wrapf(ErrInvalidLength, "%s %s has to be %d characters long", countryCode, part.name, width)
+		}
+		copy(bban[part.span[0]:], part.value)
+	}
+	check, gaps := normalise(account.NationalCheck), sp.gaps()
+	if len(check) != len(gaps) {
+		return nil, errors.Wrapf(ErrInvalidLength, "%s national check has to be %d characters long", countryCode, len(gaps))
+	}
+	for i, pos := range gaps {
+		bban[pos] = check[i]
+	}
+	if !sp.pattern().Match(bban) {
+		return nil, errors.Wrapf(ErrInvalidFormat, "%s BBAN has to follow %s structure", countryCode, sp.bban)
+	}
+	checkDigits := 98 - mod97(countryCode+"00"+string(bban))
+	return sp.iban(countryCode, twoDigits(checkDigits), string(bban)), nil
+}
+
+//Supported reports whether IBAN format of the country is known
+func Supported(countryCode string) bool {
+	_, ok := specs[countryCode]
+	return ok
+}
+
+//Electronic returns IBAN in electronic format e.g. GB29NWBK60161331926819
+func (i *IBAN) Electronic() string {
+	return i.CountryCode + i.CheckDigits + i.BBAN
+}
+
+//Print returns IBAN in print format, groups of four characters separated by space e.g. GB29 NWBK 6016 1331 9268 19
+func (i *IBAN) Print() string {
+	s := i.Electronic()
+	groups := make([]string, 0, len(s)/4+1)
+	for len(s) > 4 {
+		groups = append(groups, s[:4])
+		s = s[4:]
+	}
+	return strings.Join(append(groups, s), " ")
+}
+
+//String as in fmt.Stringer implementation, returns electronic format
+func (i *IBAN) String() string {
+	return i.Electronic()
+}
+
+func normalise(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+//mod97 computes ISO 7064 MOD 97-10 of IBAN with its first four characters moved to the end and letters replaced by numbers
+func mod97(s string) int {
+	var b strings.Builder
+	for _, r := range s[4:] + s[:4] {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteString(strconv.Itoa(int(r - 'A' + 10)))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	remainder := 0
+	for _, r := range b.String() {
+		remainder = (remainder*10 + int(r-'0')) % 97
+	}
+	return remainder
+}
+
+func twoDigits(n int) string {
+	return string([]byte{byte('0' + n/10), byte('0' + n%10)})
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,306 @@
+package iban
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+//registryExamples are example IBANs published in SWIFT IBAN registry
+var registryExamples = map[string]string{
+	"AD": "AD1200012030200359100100",
+	"AE": "AE070331234567890123456",
+	"AL": "AL47212110090000000235698741",
+	"AT": "AT611904300234573201",
+	"AZ": "AZ21NABZ00000000137010001944",
+	"BA": "BA391290079401028494",
+	"BE": "BE68539007547034",
+	"BG": "BG80BNBG96611020345678",
+	"BH": "BH67BMAG00001299123456",
+	"BR": "BR1800360305000010009795493C1",
+	"CH": "CH9300762011623852957",
+	"CR": "CR05015202001026284066",
+	"CY": "CY17002001280000001200527600",
+	"CZ": "CZ6508000000192000145399",
+	"DE": "DE89370400440532013000",
+	"DK": "DK5000400440116243",
+	"DO": "DO28BAGR00000001212453611324",
+	"EE": "EE382200221020145685",
+	"EG": "EG380019000500000000263180002",
+	"ES": "ES9121000418450200051332",
+	"FI": "FI2112345600000785",
+	"FO": "FO6264600001631634",
+	"FR": "FR1420041010050500013M02606",
+	"GB": "GB29NWBK60161331926819",
+	"GE": "GE29NB0000000101904917",
+	"GI": "GI75NWBK000000007099453",
+	"GL": "GL8964710001000206",
+	"GR": "GR1601101250000000012300695",
+	"GT": "GT82TRAJ01020000001210029690",
+	"HR": "HR1210010051863000160",
+	"HU": "HU42117730161111101800000000",
+	"IE": "IE29AIBK93115212345678",
+	"IL": "IL620108000000099999999",
+	"IS": "IS140159260076545510730339",
+	"IT": "IT60X0542811101000000123456",
+	"JO": "JO94CBJO0010000000000131000302",
+	"KW": "KW81CBKU0000000000001234560101",
+	"KZ": "KZ86125KZT5004100100",
+	"LB": "LB62099900000001001901229114",
+	"LI": "LI21088100002324013AA",
+	"LT": "LT121000011101001000",
+	"LU": "LU280019400644750000",
+	"LV": "LV80BANK0000435195001",
+	"MC": "MC5811222000010123456789030",
+	"MD": "MD24AG000225100013104168",
+	"ME": "ME25505000012345678951",
+	"MK": "MK07250120000058984",
+	"MT": "MT84MALT011000012345MTLCAST001S",
+	"MU": "MU17BOMM0101101030300200000MUR",
+	"NL": "NL91ABNA0417164300",
+	"NO": "NO9386011117947",
+	"PK": "PK36SCBL0000001123456702",
+	"PL": "PL61109010140000071219812874",
+	"PS": "PS92PALS000000000400123456702",
+	"PT": "PT50000201231234567890154",
+	"QA": "QA58DOHB00001234567890ABCDEFG",
+	"RO": "RO49AAAA1B31007593840000",
+	"RS": "RS35260005601001611379",
+	"SA": "SA0380000000608010167519",
+	"SE": "SE4550000000058398257466",
+	"SI": "SI56263300012039086",
+	"SK": "SK3112000000198742637541",
+	"SM": "SM86U0322509800000000270100",
+	"TN": "TN5910006035183598478831",
+	"TR": "TR330006100519786457841326",
+	"UA": "UA213223130000026007233566001",
+	"VA": "VA59001123000012345678",
+	"VG": "VG96VPVG0000012345678901",
+	"XK": "XK051212012345678906",
+}
+
+func TestParse_RegistryExamples(t *testing.T) {
+	for country := range specs {
+		example, ok := registryExamples[country]
+		if !assert.True(t, ok, "missing example for %s", country) {
+			continue
+		}
+		i, err := Parse(example)
+		if assert.NoError(t, err, country) {
+			assert.Equal(t, example, i.Electronic())
+			assert.Equal(t, country, i.CountryCode)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expectErr error
+		expect    *IBAN
+	}{
+		{
+			name:  "electronic format",
+			input: "GB29NWBK60161331926819",
+			expect: &IBAN{
+				CountryCode:    "GB",
+				CheckDigits:    "29",
+				BBAN:           "NWBK60161331926819",
+				BankCode:       "NWBK",
+				BranchCode:     "601613",
+				AccountNumber:  "31926819",
+				NationalBankID: "601613",
+			},
+		},
+		{
+			name:  "print format in lower case",
+			input: " de89 3704 0044 0532 0130 00 ",
+			expect: &IBAN{
+				CountryCode:    "DE",
+				CheckDigits:    "89",
+				BBAN:           "370400440532013000",
+				BankCode:       "37040044",
+				AccountNumber:  "0532013000",
+				NationalBankID: "37040044",
+			},
+		},
+		{
+			name:  "national check digits are skipped",
+			input: "ES9121000418450200051332",
+			expect: &IBAN{
+				CountryCode:    "ES",
+				CheckDigits:    "91",
+				BBAN:           "21000418450200051332",
+				BankCode:       "2100",
+				BranchCode:     "0418",
+				AccountNumber:  "0200051332",
+				NationalBankID: "21000418",
+			},
+		},
+		{
+			name:      "too short",
+			input:     "GB",
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "unsupported country",
+			input:     "US12345678901234",
+			expectErr: ErrUnsupportedCountry,
+		},
+		{
+			name:      "wrong length",
+			input:     "GB29NWBK6016133192681",
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "letters where digits expected",
+			input:     "GB29NWBK6016133192681A",
+			expectErr: ErrInvalidFormat,
+		},
+		{
+			name:      "wrong checksum",
+			input:     "GB28NWBK60161331926819",
+			expectErr: ErrInvalidChecksum,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := Parse(tt.input)
+			if tt.expectErr != nil {
+				assert.True(t, errors.Is(err, tt.expectErr), "expected %v got %v", tt.expectErr, err)
+				assert.Nil(t, i)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, i)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name      string
+		country   string
+		account   Account
+		expectErr error
+		expect    string
+	}{
+		{
+			name:    "GB",
+			country: "GB",
+			account: Account{BankCode: "NWBK", BranchCode: "601613", AccountNumber: "31926819"},
+			expect:  "GB29NWBK60161331926819",
+		},
+		{
+			name:    "DE account number is padded",
+			country: "DE",
+			account: Account{BankCode: "37040044", AccountNumber: "532013000"},
+			expect:  "DE89370400440532013000",
+		},
+		{
+			name:    "NL",
+			country: "nl",
+			account: Account{BankCode: "ABNA", AccountNumber: "0417164300"},
+			expect:  "NL91ABNA0417164300",
+		},
+		{
+			name:    "ES national check digits",
+			country: "ES",
+			account: Account{BankCode: "2100", BranchCode: "0418", NationalCheck: "45", AccountNumber: "0200051332"},
+			expect:  "ES9121000418450200051332",
+		},
+		{
+			name:    "IT CIN",
+			country: "IT",
+			account: Account{NationalCheck: "X", BankCode: "05428", BranchCode: "11101", AccountNumber: "000000123456"},
+			expect:  "IT60X0542811101000000123456",
+		},
+		{
+			name:      "ES without national check digits",
+			country:   "ES",
+			account:   Account{BankCode: "2100", BranchCode: "0418", AccountNumber: "0200051332"},
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "GB without branch code",
+			country:   "GB",
+			account:   Account{BankCode: "NWBK601613", AccountNumber: "31926819"},
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "DE doesn't use branch code",
+			country:   "DE",
+			account:   Account{BankCode: "37040044", BranchCode: "1", AccountNumber: "532013000"},
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "unsupported country",
+			country:   "US",
+			expectErr: ErrUnsupportedCountry,
+			account:   Account{BankCode: "021000021", AccountNumber: "123456789"},
+		},
+		{
+			name:      "account number too long",
+			country:   "GB",
+			account:   Account{BankCode: "NWBK", BranchCode: "601613", AccountNumber: "319268190"},
+			expectErr: ErrInvalidLength,
+		},
+		{
+			name:      "bank code isn't alphabetic",
+			country:   "GB",
+			account:   Account{BankCode: "1234", BranchCode: "601613", AccountNumber: "31926819"},
+			expectErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := Build(tt.country, tt.account)
+			if tt.expectErr != nil {
+				assert.True(t, errors.Is(err, tt.expectErr), "expected %v got %v", tt.expectErr, err)
+				assert.Nil(t, i)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expect, i.Electronic())
+				_, err = Parse(i.Electronic())
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+//TestBuild_RegistryExamples rebuilds every registry example from its components
+func TestBuild_RegistryExamples(t *testing.T) {
+	for country, example := range registryExamples {
+		t.Run(country, func(t *testing.T) {
+			parsed, err := Parse(example)
+			require.NoError(t, err)
+			var check []byte
+			for _, pos := range specs[country].gaps() {
+				check = append(check, parsed.BBAN[pos])
+			}
+			built, err := Build(country, Account{
+				BankCode:      parsed.BankCode,
+				BranchCode:    parsed.BranchCode,
+				AccountNumber: parsed.AccountNumber,
+				NationalCheck: string(check),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, example, built.Electronic())
+		})
+	}
+}
+
+func TestIBAN_Print(t *testing.T) {
+	i, err := Parse("GB29NWBK60161331926819")
+	require.NoError(t, err)
+	assert.Equal(t, "GB29 NWBK 6016 1331 9268 19", i.Print())
+	assert.Equal(t, "GB29NWBK60161331926819", i.String())
+
+	i, err = Parse("BE68539007547034")
+	require.NoError(t, err)
+	assert.Equal(t, "BE68 5390 0754 7034", i.Print())
+}
@@ -0,0 +1,28 @@
+//Package neterr classifies errors of requests which didn't get any response, it's shared by form.Retryable
+//and retries of client.DefaultClient so both agree on what is worth repeating
+package neterr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"syscall"
+)
+
+//Temporary reports whether request failed with err may succeed when repeated i.e. it timed out or connection was
+//refused, reset or aborted. Cancelled or expired contexts, TLS and certificate failures, malformed URLs and other
+//errors aren't temporary.
+func Temporary(err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED):
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
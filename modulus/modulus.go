@@ -0,0 +1,272 @@
+package modulus
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	methodMod10 = "MOD10"
+	methodMod11 = "MOD11"
+	methodDblAl = "DBLAL"
+
+	//sort codes used in place of the original one by exceptions 8 and 9
+	exception8SortCode = "090126"
+	exception9SortCode = "309634"
+)
+
+var (
+	//ErrInvalidSortCode is returned when sort code isn't 6 digits
+	ErrInvalidSortCode = errors.New("sort code has to be 6 digits")
+	//ErrInvalidAccountNumber is returned when account number isn't 8 digits
+	ErrInvalidAccountNumber = errors.New("account number has to be 8 digits")
+
+	//weights replacing the ones from weight table by exception 2
+	exception2Weights     = [14]int{0, 0, 1, 2, 5, 3, 6, 4, 8, 7, 10, 9, 3, 1}
+	exception2WeightsGIs9 = [14]int{0, 0, 0, 0, 0, 0, 0, 0, 8, 7, 10, 9, 3, 1}
+)
+
+//positions of account number digits in the 14 digits made of sort code (u-z) followed by account number (a-h)
+const (
+	posA = 6 + iota
+	posB
+	posC
+	_
+	_
+	_
+	posG
+	posH
+)
+
+type rule struct {
+	from, to  string
+	method    string
+	weights   [14]int
+	exception int
+}
+
+//Checker validates UK sort code and account number pairs using modulus weight table and sorting code substitution table
+//as published by Vocalink. It's safe for concurrent use.
+type Checker struct {
+	rules         []rule
+	substitutions map[string]string
+}
+
+//LoadFiles reads weight table (valacdos.txt) and substitution table (scsubtab.txt) from disk
+func LoadFiles(weightsPath, substitutionsPath string) (*Checker, error) {
+	weights, err := os.Open(weightsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open weight table")
+	}
+	defer weights.Close()
+	substitutions, err := os.Open(substitutionsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open substitution table")
+	}
+	defer substitutions.Close()
+	return Load(weights, substitutions)
+}
+
+//Load reads weight table and substitution table, each line of weight table holds sort code range, method, 14 weights and
+//optional exception number, each line of substitution table holds original and substituted sort code
+func Load(weights, substitutions io.Reader) (*Checker, error) {
+	c := &Checker{substitutions: map[string]string{}}
+	err := scanLines(weights, func(n int, fields []string) error {
+		if len(fields) != 17 && len(fields) != 18 {
+			return errors.Errorf("weight table line %d: expected 17 or 18 fields got %d", n, len(fields))
+		}
+		r := rule{from: fields[0], to: fields[1], method: fields[2]}
+		if !isDigits(r.from, 6) || !isDigits(r.to, 6) {
+			return errors.Errorf("weight table line %d: invalid sort code range", n)
+		}
+		if r.method != methodMod10 && r.method != methodMod11 && r.method != methodDblAl {
+			return errors.Errorf("weight table line %d: unknown method %s", n, r.method)
+		}
+		for i, f := range fields[3:17] {
+			w, err := strconv.Atoi(f)
+			if err != nil {
+				return errors.Wrapf(err, "weight table line %d: invalid weight", n)
+			}
+			r.weights[i] = w
+		}
+		if len(fields) == 18 {
+			ex, err := strconv.Atoi(fields[17])
+			if err != nil {
+				return errors.Wrapf(err, "weight table line %d: invalid exception", n)
+			}
+			r.exception = ex
+		}
+		c.rules = append(c.rules, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	//order of rules sharing sort code range matters, hence stable sort
+	sort.SliceStable(c.rules, func(i, j int) bool { return c.rules[i].from < c.rules[j].from })
+
+	err = scanLines(substitutions, func(n int, fields []string) error {
+		if len(fields) != 2 || !isDigits(fields[0], 6) || !isDigits(fields[1], 6) {
+			return errors.Errorf("substitution table line %d: expected original and substituted sort code", n)
+		}
+		c.substitutions[fields[0]] = fields[1]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//Check reports whether account number is valid for sort code. Sort codes not present in weight table can't be checked
+//and are reported as valid. Sort code may contain dashes or spaces e.g. 40-03-00.
+func (c *Checker) Check(sortCode, accountNumber string) (bool, error) {
+	sortCode = strings.NewReplacer("-", "", " ", "").Replace(sortCode)
+	if !isDigits(sortCode, 6) {
+		return false, ErrInvalidSortCode
+	}
+	if !isDigits(accountNumber, 8) {
+		return false, ErrInvalidAccountNumber
+	}
+	rules := c.lookup(sortCode)
+	if len(rules) == 0 {
+		return true, nil
+	}
+	digits := toDigits(sortCode + accountNumber)
+	//exception 6: foreign currency accounts can't be checked
+	if rules[0].exception == 6 && digits[posA] >= 4 && digits[posA] <= 8 && digits[posG] == digits[posH] {
+		return true, nil
+	}
+
+	first := c.check(rules[0], sortCode, accountNumber)
+	if !first && rules[0].exception == 14 {
+		return c.exception14(rules[0], sortCode, accountNumber), nil
+	}
+	if len(rules) == 1 {
+		return first, nil
+	}
+
+	second := rules[1]
+	switch {
+	case rules[0].exception == 2 && second.exception == 9:
+		return first || c.check(second, exception9SortCode, accountNumber), nil
+	case rules[0].exception == 10 && second.exception == 11, rules[0].exception == 12 && second.exception == 13:
+		return first || c.check(second, sortCode, accountNumber), nil
+	case !first:
+		return false, nil
+	case second.exception == 3 && (digits[posC] == 6 || digits[posC] == 9):
+		return true, nil
+	}
+	return c.check(second, sortCode, accountNumber), nil
+}
+
+func (c *Checker) lookup(sortCode string) []rule {
+	i := sort.Search(len(c.rules), func(i int) bool { return c.rules[i].from > sortCode })
+	var rules []rule
+	for _, r := range c.rules[:i] {
+		if r.to >= sortCode {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func (c *Checker) check(r rule, sortCode, accountNumber string) bool {
+	switch r.exception {
+	case 5:
+		if substitute, ok := c.substitutions[sortCode]; ok {
+			sortCode = substitute
+		}
+	case 8:
+		sortCode = exception8SortCode
+	}
+	digits := toDigits(sortCode + accountNumber)
+	weights := r.weights
+	switch {
+	case r.exception == 2 && digits[posA] != 0 && digits[posG] != 9:
+		weights = exception2Weights
+	case r.exception == 2 && digits[posA] != 0:
+		weights = exception2WeightsGIs9
+	case r.exception == 7 && digits[posG] == 9,
+		r.exception == 10 && (digits[posA] == 0 || digits[posA] == 9) && digits[posB] == 9 && digits[posG] == 9:
+		for i := 0; i <= posB; i++ {
+			weights[i] = 0
+		}
+	}
+
+	total := 0
+	for i, d := range digits {
+		p := d * weights[i]
+		if r.method == methodDblAl {
+			p = p/10 + p%10
+		}
+		total += p
+	}
+	if r.exception == 1 {
+		total += 27
+	}
+
+	switch {
+	case r.method == methodMod11 && r.exception == 4:
+		return total%11 == digits[posG]*10+digits[posH]
+	case r.method == methodMod11 && r.exception == 5:
+		remainder := total % 11
+		if remainder == 1 {
+			return false
+		}
+		return (11-remainder)%11 == digits[posG]
+	case r.exception == 5:
+		return (10-total%10)%10 == digits[posH]
+	case r.method == methodMod11:
+		return total%11 == 0
+	}
+	return total%10 == 0
+}
+
+//exception14 retries the check with account number shifted right when its last digit is 0, 1 or 9
+func (c *Checker) exception14(r rule, sortCode, accountNumber string) bool {
+	switch accountNumber[7] {
+	case '0', '1', '9':
+		return c.check(r, sortCode, "0"+accountNumber[:7])
+	}
+	return false
+}
+
+func scanLines(r io.Reader, fn func(n int, fields []string) error) error {
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if err := fn(n, fields); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(s.Err(), "failed to read table")
+}
+
+func toDigits(s string) [14]int {
+	var digits [14]int
+	for i := range digits {
+		digits[i] = int(s[i] - '0')
+	}
+	return digits
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
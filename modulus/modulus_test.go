@@ -0,0 +1,133 @@
+package modulus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+//TestChecker_Check uses test cases published in Vocalink modulus checking specification,
+//testdata holds sample weight and substitution tables in Vocalink format covering sort codes those cases refer to
+func TestChecker_Check(t *testing.T) {
+	c, err := LoadFiles("testdata/valacdos.txt", "testdata/scsubtab.txt")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		sortCode      string
+		accountNumber string
+		expectValid   bool
+	}{
+		{name: "pass modulus 10 check", sortCode: "089999", accountNumber: "66374958", expectValid: true},
+		{name: "pass modulus 11 check", sortCode: "107999", accountNumber: "88837491", expectValid: true},
+		{name: "pass double alternate check", sortCode: "202959", accountNumber: "63748472", expectValid: true},
+		{name: "exception 10 & 11 first check passes second fails", sortCode: "871427", accountNumber: "46238510", expectValid: true},
+		{name: "exception 10 & 11 first check fails second passes", sortCode: "872427", accountNumber: "46238510", expectValid: true},
+		{name: "exception 10 ab=09 and g=9", sortCode: "871427", accountNumber: "09123496", expectValid: true},
+		{name: "exception 10 ab=99 and g=9", sortCode: "871427", accountNumber: "99123496", expectValid: true},
+		{name: "exception 3 c=6 ignores second check", sortCode: "820000", accountNumber: "73688637", expectValid: true},
+		{name: "exception 3 c=9 ignores second check", sortCode: "827999", accountNumber: "73988638", expectValid: true},
+		{name: "exception 3 both checks pass", sortCode: "827101", accountNumber: "28748352", expectValid: true},
+		{name: "exception 4 remainder equals check digit", sortCode: "134020", accountNumber: "63849203", expectValid: true},
+		{name: "exception 1 adds 27", sortCode: "118765", accountNumber: "64371389", expectValid: true},
+		{name: "exception 6 foreign currency account", sortCode: "200915", accountNumber: "41011166", expectValid: true},
+		{name: "exception 5 check passes", sortCode: "938611", accountNumber: "07806039", expectValid: true},
+		{name: "exception 5 check passes with substitution", sortCode: "938600", accountNumber: "42368003", expectValid: true},
+		{name: "exception 5 both checks produce remainder 0", sortCode: "938063", accountNumber: "55065200", expectValid: true},
+		{name: "exception 7 passes but would fail standard check", sortCode: "772798", accountNumber: "99345694", expectValid: true},
+		{name: "exception 8 check passes", sortCode: "086090", accountNumber: "06774744", expectValid: true},
+		{name: "exception 2 & 9 first check passes", sortCode: "309070", accountNumber: "02355688", expectValid: true},
+		{name: "exception 2 & 9 second check passes with substitution", sortCode: "309070", accountNumber: "12345668", expectValid: true},
+		{name: "exception 2 & 9 a!=0 and g!=9", sortCode: "309070", accountNumber: "12345677", expectValid: true},
+		{name: "exception 2 & 9 a!=0 and g=9", sortCode: "309070", accountNumber: "99345694", expectValid: true},
+		{name: "exception 5 first check digit correct second incorrect", sortCode: "938063", accountNumber: "15764273"},
+		{name: "exception 5 first check digit incorrect second correct", sortCode: "938063", accountNumber: "15764264"},
+		{name: "exception 5 first check digit incorrect with remainder 1", sortCode: "938063", accountNumber: "15763217"},
+		{name: "exception 1 fails double alternate check", sortCode: "118765", accountNumber: "64371388"},
+		{name: "pass modulus 11 check and fail double alternate check", sortCode: "203099", accountNumber: "66831036"},
+		{name: "fail modulus 11 check and pass double alternate check", sortCode: "203099", accountNumber: "58716970"},
+		{name: "fail modulus 10 check", sortCode: "089999", accountNumber: "66374959"},
+		{name: "fail modulus 11 check", sortCode: "107999", accountNumber: "88837493"},
+		{name: "exception 12 & 13 passes modulus 11 check", sortCode: "074456", accountNumber: "12345112", expectValid: true},
+		{name: "exception 12 & 13 passes both checks", sortCode: "070116", accountNumber: "34012583", expectValid: true},
+		{name: "exception 12 & 13 passes modulus 10 check", sortCode: "074456", accountNumber: "11104102", expectValid: true},
+		{name: "exception 14 second check passes", sortCode: "180002", accountNumber: "00000190", expectValid: true},
+		{name: "sort code with dashes", sortCode: "08-99-99", accountNumber: "66374958", expectValid: true},
+		{name: "sort code not in weight table", sortCode: "400300", accountNumber: "41426819", expectValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := c.Check(tt.sortCode, tt.accountNumber)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectValid, valid)
+		})
+	}
+}
+
+func TestChecker_CheckMalformedInput(t *testing.T) {
+	c, err := Load(strings.NewReader(""), strings.NewReader(""))
+	require.NoError(t, err)
+
+	_, err = c.Check("08999", "66374958")
+	assert.Equal(t, ErrInvalidSortCode, err)
+	_, err = c.Check("089999", "6637495")
+	assert.Equal(t, ErrInvalidAccountNumber, err)
+	_, err = c.Check("089999", "6637495A")
+	assert.Equal(t, ErrInvalidAccountNumber, err)
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name             string
+		weights          string
+		substitutions    string
+		expectErrMessage string
+	}{
+		{
+			name:             "missing weights",
+			weights:          "089000 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7",
+			expectErrMessage: "weight table line 1: expected 17 or 18 fields got 16",
+		},
+		{
+			name:             "unknown method",
+			weights:          "089000 089999 MOD12 0 0 0 0 0 0 7 1 3 7 1 3 7 1",
+			expectErrMessage: "weight table line 1: unknown method MOD12",
+		},
+		{
+			name:             "invalid sort code range",
+			weights:          "\n08900 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 1",
+			expectErrMessage: "weight table line 2: invalid sort code range",
+		},
+		{
+			name:             "invalid substitution",
+			substitutions:    "938600",
+			expectErrMessage: "substitution table line 1: expected original and substituted sort code",
+		},
+		{
+			name:          "success",
+			weights:       "089000 089999 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 1\n938000 938696 MOD11 1 6 2 3 8 2 5 4 7 9 3 10 0 0 5",
+			substitutions: "938600 938611",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(strings.NewReader(tt.weights), strings.NewReader(tt.substitutions))
+			if tt.expectErrMessage != "" {
+				assert.Nil(t, c)
+				assert.EqualError(t, err, tt.expectErrMessage)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, c)
+			}
+		})
+	}
+}
+
+func TestLoadFiles_MissingFile(t *testing.T) {
+	c, err := LoadFiles("testdata/missing.txt", "testdata/scsubtab.txt")
+	assert.Nil(t, c)
+	assert.Regexp(t, "failed to open weight table.*", err.Error())
+}
@@ -0,0 +1,16 @@
+package form
+
+import "github.com/Gobonoid/form/modulus"
+
+//Option definition for AccountAPIClient
+type Option func(a *AccountAPIClient)
+
+//WithRuleRegistry allows injecting RuleRegistry used to validate accounts, if not used DefaultRuleRegistry is being used
+func WithRuleRegistry(r *RuleRegistry) Option {
+	return func(a *AccountAPIClient) { a.rules = r }
+}
+
+//WithModulusChecker runs ModulusCheck on GB accounts after rules of the RuleRegistry, the registry itself is left untouched
+func WithModulusChecker(c *modulus.Checker) Option {
+	return func(a *AccountAPIClient) { a.modulus = c }
+}
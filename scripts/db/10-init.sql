@@ -1,2 +0,0 @@
-CREATE USER interview_accountapi_user WITH PASSWORD '123';
-CREATE DATABASE interview_accountapi OWNER interview_accountapi_user;
\ No newline at end of file
@@ -0,0 +1,230 @@
+package form
+
+import (
+	"fmt"
+	"github.com/Gobonoid/form/bic"
+	"github.com/Gobonoid/form/iban"
+	"github.com/Gobonoid/form/modulus"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	ibanRegexp = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+)
+
+//FieldRule describes constraints of a single account attribute in a given country
+type FieldRule struct {
+	Required     bool
+	NotSupported bool
+	//Pattern is checked only when value is present, Format describes it in human readable way for violation message
+	Pattern *regexp.Regexp
+	Format  string
+}
+
+//CountryRules groups FieldRules of account attributes that differ between countries
+type CountryRules struct {
+	BankID        FieldRule
+	BankIDCode    FieldRule
+	Bic           FieldRule
+	AccountNumber FieldRule
+	Iban          FieldRule
+	//Validators run once all field rules are satisfied, they cover checks spanning several attributes
+	Validators []AttributesValidator
+}
+
+//AttributesValidator checks relationship between account attributes which FieldRule can't express
+type AttributesValidator func(attrs *AccountAttributes) ValidationErrors
+
+//RuleRegistry holds CountryRules keyed by ISO 3166-1 alpha-2 country code, safe for concurrent use.
+//Accounts in countries without registered rules are left for API to validate.
+type RuleRegistry struct {
+	mu    sync.RWMutex
+	rules map[string]CountryRules
+}
+
+//NewRuleRegistry returns empty RuleRegistry
+func NewRuleRegistry() *RuleRegistry {
+	return &RuleRegistry{rules: map[string]CountryRules{}}
+}
+
+//DefaultRuleRegistry returns RuleRegistry populated with rules published in form accounts API documentation
+func DefaultRuleRegistry() *RuleRegistry {
+	r := NewRuleRegistry()
+	for country, rules := range defaultCountryRules() {
+		r.Register(country, rules)
+	}
+	return r
+}
+
+//Register sets rules for country replacing previously registered ones
+func (r *RuleRegistry) Register(country string, rules CountryRules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules[country] = rules
+}
+
+//Rules returns rules registered for country
+func (r *RuleRegistry) Rules(country string) (CountryRules, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules, ok := r.rules[country]
+	return rules, ok
+}
+
+//Validate checks attributes against rules of their country and returns all violations at once as ValidationErrors
+func (r *RuleRegistry) Validate(attrs *AccountAttributes) error {
+	if violations := r.validate(attrs); len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+func (r *RuleRegistry) validate(attrs *AccountAttributes) ValidationErrors {
+	if attrs == nil {
+		return ValidationErrors{{Field: "attributes", Code: ViolationRequired, Message: "Attributes property can't be empty"}}
+	}
+	if attrs.Country == nil || *attrs.Country == "" {
+		return ValidationErrors{{Field: "attributes.country", Code: ViolationRequired, Message: "attributes.country is required"}}
+	}
+	country := *attrs.Country
+	if !countryCodeRegexp.MatchString(country) {
+		return ValidationErrors{{
+			Field:   "attributes.country",
+			Code:    ViolationInvalidFormat,
+			Message: "attributes.country has to be ISO 3166-1 alpha-2 code",
+		}}
+	}
+	var violations ValidationErrors
+	if rules, ok := r.Rules(country); ok {
+		violations = rules.validate(attrs, country)
+	}
+	if attrs.Bic != "" && !violations.has("attributes.bic") {
+		violations = append(violations, validateBic(attrs.Bic, country)...)
+	}
+	if attrs.Iban != "" && !violations.has("attributes.iban") {
+		violations = append(violations, validateIban(attrs, country)...)
+	}
+	return violations
+}
+
+func (rules CountryRules) validate(attrs *AccountAttributes, country string) ValidationErrors {
+	var violations ValidationErrors
+	for _, f := range []struct {
+		field string
+		value string
+		rule  FieldRule
+	}{
+		{field: "attributes.bank_id", value: attrs.BankID, rule: rules.BankID},
+		{field: "attributes.bank_id_code", value: attrs.BankIDCode, rule: rules.BankIDCode},
+		{field: "attributes.bic", value: attrs.Bic, rule: rules.Bic},
+		{field: "attributes.account_number", value: attrs.AccountNumber, rule: rules.AccountNumber},
+		{field: "attributes.iban", value: attrs.Iban, rule: rules.Iban},
+	} {
+		if v := f.rule.check(f.field, f.value, country); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+	for _, validate := range rules.Validators {
+		violations = append(violations, validate(attrs)...)
+	}
+	return violations
+}
+
+//ModulusCheck returns AttributesValidator verifying that account number is valid for sort code held in bank_id
+func ModulusCheck(c *modulus.Checker) AttributesValidator {
+	return func(attrs *AccountAttributes) ValidationErrors {
+		if attrs.BankID == "" || attrs.AccountNumber == "" {
+			return nil
+		}
+		valid, err := c.Check(attrs.BankID, attrs.AccountNumber)
+		if err != nil {
+			return ValidationErrors{{Field: "attributes.account_number", Code: ViolationInvalidFormat, Message: "attributes.account_number " + err.Error()}}
+		}
+		if !valid {
+			return ValidationErrors{{
+				Field:   "attributes.account_number",
+				Code:    ViolationInvalidChecksum,
+				Message: fmt.Sprintf("attributes.account_number fails modulus check for sort code %s", attrs.BankID),
+			}}
+		}
+		return nil
+	}
+}
+
+func (rule FieldRule) check(field, value, country string) *FieldViolation {
+	switch {
+	case value == "" && rule.Required:
+		return &FieldViolation{Field: field, Code: ViolationRequired, Message: fmt.Sprintf("%s is required in %s", field, country)}
+	case value == "":
+		return nil
+	case rule.NotSupported:
+		return &FieldViolation{Field: field, Code: ViolationNotSupported, Message: fmt.Sprintf("%s isn't supported in %s", field, country)}
+	case rule.Pattern != nil && !rule.Pattern.MatchString(value):
+		return &FieldViolation{
+			Field:   field,
+			Code:    ViolationInvalidFormat,
+			Message: fmt.Sprintf("%s has to be %s in %s", field, rule.Format, country),
+		}
+	}
+	return nil
+}
+
+func validateBic(s, country string) ValidationErrors {
+	b, err := bic.Parse(s)
+	if err != nil {
+		return ValidationErrors{{Field: "attributes.bic", Code: ViolationInvalidFormat, Message: "attributes.bic isn't valid: " + err.Error()}}
+	}
+	if b.Country != country {
+		return ValidationErrors{{
+			Field:   "attributes.bic",
+			Code:    ViolationMismatch,
+			Message: fmt.Sprintf("attributes.bic country %s doesn't match attributes.country %s", b.Country, country),
+		}}
+	}
+	return nil
+}
+
+//validateIban verifies IBAN checksum and that it agrees with domestic account details,
+//IBANs of countries unknown to iban package are left for API to validate
+func validateIban(attrs *AccountAttributes, country string) ValidationErrors {
+	i, err := iban.Parse(attrs.Iban)
+	switch {
+	case errors.Is(err, iban.ErrUnsupportedCountry):
+		return nil
+	case errors.Is(err, iban.ErrInvalidChecksum):
+		return ValidationErrors{{Field: "attributes.iban", Code: ViolationInvalidChecksum, Message: "attributes.iban checksum is invalid"}}
+	case err != nil:
+		return ValidationErrors{{Field: "attributes.iban", Code: ViolationInvalidFormat, Message: "attributes.iban isn't valid: " + err.Error()}}
+	}
+
+	if i.CountryCode != country {
+		return ValidationErrors{{
+			Field:   "attributes.iban",
+			Code:    ViolationMismatch,
+			Message: fmt.Sprintf("attributes.iban country %s doesn't match attributes.country %s", i.CountryCode, country),
+		}}
+	}
+	var violations ValidationErrors
+	if attrs.BankID != "" && i.NationalBankID != "" && attrs.BankID != i.NationalBankID {
+		violations = append(violations, FieldViolation{
+			Field:   "attributes.bank_id",
+			Code:    ViolationMismatch,
+			Message: fmt.Sprintf("attributes.bank_id doesn't match bank identifier %s of attributes.iban", i.NationalBankID),
+		})
+	}
+	//domestic account numbers are often written without leading zeros used to pad them in IBAN
+	if attrs.AccountNumber != "" && strings.TrimLeft(attrs.AccountNumber, "0") != strings.TrimLeft(i.AccountNumber, "0") {
+		violations = append(violations, FieldViolation{
+			Field:   "attributes.account_number",
+			Code:    ViolationMismatch,
+			Message: fmt.Sprintf("attributes.account_number doesn't match account number %s of attributes.iban", i.AccountNumber),
+		})
+	}
+	return violations
+}
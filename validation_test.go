@@ -0,0 +1,281 @@
+package form_test
+
+import (
+	"context"
+	"errors"
+	"github.com/Gobonoid/form"
+	"github.com/Gobonoid/form/formtest"
+	"github.com/Gobonoid/form/modulus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func TestRuleRegistry_Validate(t *testing.T) {
+	country := func(c string) *string { return &c }
+
+	tests := []struct {
+		name             string
+		registry         *form.RuleRegistry
+		attrs            *form.AccountAttributes
+		expectViolations form.ValidationErrors
+	}{
+		{
+			name:             "empty attributes",
+			registry:         form.DefaultRuleRegistry(),
+			expectViolations: form.ValidationErrors{{Field: "attributes", Code: form.ViolationRequired, Message: "Attributes property can't be empty"}},
+		},
+		{
+			name:             "missing country",
+			registry:         form.DefaultRuleRegistry(),
+			attrs:            &form.AccountAttributes{},
+			expectViolations: form.ValidationErrors{{Field: "attributes.country", Code: form.ViolationRequired, Message: "attributes.country is required"}},
+		},
+		{
+			name:     "country isn't ISO code",
+			registry: form.DefaultRuleRegistry(),
+			attrs:    &form.AccountAttributes{Country: country("GBR")},
+			expectViolations: form.ValidationErrors{{
+				Field:   "attributes.country",
+				Code:    form.ViolationInvalidFormat,
+				Message: "attributes.country has to be ISO 3166-1 alpha-2 code",
+			}},
+		},
+		{
+			name:     "country without rules",
+			registry: form.DefaultRuleRegistry(),
+			attrs:    &form.AccountAttributes{Country: country("ZZ")},
+		},
+		{
+			name:     "valid GB account",
+			registry: form.DefaultRuleRegistry(),
+			attrs:    formtest.ValidGBAttributes("fake account"),
+		},
+		{
+			name:     "GB account without account number generated by the API",
+			registry: form.DefaultRuleRegistry(),
+			attrs: func() *form.AccountAttributes {
+				attrs := formtest.ValidGBAttributes("fake account")
+				attrs.AccountNumber = ""
+				return attrs
+			}(),
+		},
+		{
+			name:     "BIC with alphanumeric institution code",
+			registry: form.DefaultRuleRegistry(),
+			attrs: func() *form.AccountAttributes {
+				attrs := formtest.ValidGBAttributes("fake account")
+				attrs.Bic = "N4BKGB22"
+				return attrs
+			}(),
+		},
+		{
+			name:     "GB account reports all violations",
+			registry: form.DefaultRuleRegistry(),
+			attrs: &form.AccountAttributes{
+				AccountNumber: "1234",
+				BankID:        "40030",
+				BankIDCode:    "DEBLZ",
+				Country:       country("GB"),
+			},
+			expectViolations: form.ValidationErrors{
+				{Field: "attributes.bank_id", Code: form.ViolationInvalidFormat, Message: "attributes.bank_id has to be 6 digits in GB"},
+				{Field: "attributes.bank_id_code", Code: form.ViolationInvalidFormat, Message: "attributes.bank_id_code has to be GBDSC in GB"},
+				{Field: "attributes.bic", Code: form.ViolationRequired, Message: "attributes.bic is required in GB"},
+				{
+					Field:   "attributes.account_number",
+					Code:    form.ViolationInvalidFormat,
+					Message: "attributes.account_number has to be 8 digits in GB",
+				},
+			},
+		},
+		{
+			name:     "DE account",
+			registry: form.DefaultRuleRegistry(),
+			attrs: &form.AccountAttributes{
+				BankID:     "37040044",
+				BankIDCode: "DEBLZ",
+				Country:    country("DE"),
+			},
+		},
+		{
+			name:     "not supported field",
+			registry: form.DefaultRuleRegistry(),
+			attrs: &form.AccountAttributes{
+				BankID:  "123",
+				Bic:     "ABNANL2A",
+				Country: country("NL"),
+			},
+			expectViolations: form.ValidationErrors{{Field: "attributes.bank_id", Code: form.ViolationNotSupported, Message: "attributes.bank_id isn't supported in NL"}},
+		},
+		{
+			name:     "GB account with matching IBAN",
+			registry: form.DefaultRuleRegistry(),
+			attrs: func() *form.AccountAttributes {
+				attrs := formtest.ValidGBAttributes("fake account")
+				attrs.Iban = "GB16NWBK40030041426819"
+				return attrs
+			}(),
+		},
+		{
+			name:     "IBAN with invalid checksum",
+			registry: form.DefaultRuleRegistry(),
+			attrs: func() *form.AccountAttributes {
+				attrs := formtest.ValidGBAttributes("fake account")
+				attrs.Iban = "GB17NWBK40030041426819"
+				return attrs
+			}(),
+			expectViolations: form.ValidationErrors{{Field: "attributes.iban", Code: form.ViolationInvalidChecksum, Message: "attributes.iban checksum is invalid"}},
+		},
+		{
+			name:     "IBAN doesn't match bank_id and account_number",
+			registry: form.DefaultRuleRegistry(),
+			attrs: func() *form.AccountAttributes {
+				attrs := formtest.ValidGBAttributes("fake account")
+				attrs.Iban = "GB29NWBK60161331926819"
+				return attrs
+			}(),
+			expectViolations: form.ValidationErrors{
+				{
+					Field:   "attributes.bank_id",
+					Code:    form.ViolationMismatch,
+					Message: "attributes.bank_id doesn't match bank identifier 601613 of attributes.iban",
+				},
+				{
+					Field:   "attributes.account_number",
+					Code:    form.ViolationMismatch,
+					Message: "attributes.account_number doesn't match account number 31926819 of attributes.iban",
+				},
+			},
+		},
+		{
+			name:     "IBAN from another country",
+			registry: form.DefaultRuleRegistry(),
+			attrs: &form.AccountAttributes{
+				BankID:     "37040044",
+				BankIDCode: "DEBLZ",
+				Country:    country("DE"),
+				Iban:       "GB29NWBK60161331926819",
+			},
+			expectViolations: form.ValidationErrors{{
+				Field:   "attributes.iban",
+				Code:    form.ViolationMismatch,
+				Message: "attributes.iban country GB doesn't match attributes.country DE",
+			}},
+		},
+		{
+			name:     "DE account number matches IBAN without padding",
+			registry: form.DefaultRuleRegistry(),
+			attrs: &form.AccountAttributes{
+				AccountNumber: "532013000",
+				BankID:        "37040044",
+				BankIDCode:    "DEBLZ",
+				Country:       country("DE"),
+				Iban:          "DE89370400440532013000",
+			},
+		},
+		{
+			name:     "BIC from another country",
+			registry: form.DefaultRuleRegistry(),
+			attrs: func() *form.AccountAttributes {
+				attrs := formtest.ValidGBAttributes("fake account")
+				attrs.Bic = "DEUTDEFF500"
+				return attrs
+			}(),
+			expectViolations: form.ValidationErrors{{
+				Field:   "attributes.bic",
+				Code:    form.ViolationMismatch,
+				Message: "attributes.bic country DE doesn't match attributes.country GB",
+			}},
+		},
+		{
+			name: "custom rules",
+			registry: func() *form.RuleRegistry {
+				r := form.NewRuleRegistry()
+				r.Register("ZZ", form.CountryRules{
+					BankID: form.FieldRule{Required: true, Pattern: regexp.MustCompile(`^Z+$`), Format: "only Z"},
+				})
+				return r
+			}(),
+			attrs:            &form.AccountAttributes{BankID: "ABC", Country: country("ZZ")},
+			expectViolations: form.ValidationErrors{{Field: "attributes.bank_id", Code: form.ViolationInvalidFormat, Message: "attributes.bank_id has to be only Z in ZZ"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.registry.Validate(tt.attrs)
+			if tt.expectViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.expectViolations, err)
+
+			var validationErr form.ErrValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, err.Error(), validationErr.Error())
+		})
+	}
+}
+
+func TestModulusCheck(t *testing.T) {
+	checker, err := modulus.LoadFiles("modulus/testdata/valacdos.txt", "modulus/testdata/scsubtab.txt")
+	require.NoError(t, err)
+
+	registry := form.DefaultRuleRegistry()
+	gb, _ := registry.Rules("GB")
+	gb.Validators = append(gb.Validators, form.ModulusCheck(checker))
+	registry.Register("GB", gb)
+
+	attrs := formtest.ValidGBAttributes("fake account")
+	attrs.BankID = "089999"
+	attrs.AccountNumber = "66374958"
+	assert.NoError(t, registry.Validate(attrs))
+
+	attrs.AccountNumber = "66374959"
+	assert.Equal(t, form.ValidationErrors{{
+		Field:   "attributes.account_number",
+		Code:    form.ViolationInvalidChecksum,
+		Message: "attributes.account_number fails modulus check for sort code 089999",
+	}}, registry.Validate(attrs))
+
+	attrs.AccountNumber = "6637495"
+	err = registry.Validate(attrs)
+	assert.Equal(t, "attributes.account_number", err.(form.ValidationErrors)[0].Field)
+	assert.Equal(t, form.ViolationInvalidFormat, err.(form.ValidationErrors)[0].Code)
+}
+
+func TestWithModulusChecker_SharedRegistry(t *testing.T) {
+	checker, err := modulus.LoadFiles("modulus/testdata/valacdos.txt", "modulus/testdata/scsubtab.txt")
+	require.NoError(t, err)
+
+	registry := form.DefaultRuleRegistry()
+	var clients []*form.AccountAPIClient
+	for i := 0; i < 3; i++ {
+		clients = append(clients, form.NewAccountAPIClient(nil, form.WithRuleRegistry(registry), form.WithModulusChecker(checker)))
+	}
+
+	attrs := formtest.ValidGBAttributes("fake account")
+	attrs.BankID = "089999"
+	attrs.AccountNumber = "66374959"
+	req := form.CreateAccountReq{Attributes: attrs}
+	for _, c := range clients {
+		err := c.CreateAccount(context.Background(), req)
+		assert.Equal(t, form.ValidationErrors{{
+			Field:   "attributes.account_number",
+			Code:    form.ViolationInvalidChecksum,
+			Message: "attributes.account_number fails modulus check for sort code 089999",
+		}}, err)
+	}
+
+	gb, _ := registry.Rules("GB")
+	assert.Empty(t, gb.Validators)
+	assert.NoError(t, registry.Validate(attrs))
+
+	//registry without GB rules is left without them
+	empty := form.NewRuleRegistry()
+	form.NewAccountAPIClient(nil, form.WithRuleRegistry(empty), form.WithModulusChecker(checker))
+	_, ok := empty.Rules("GB")
+	assert.False(t, ok)
+}